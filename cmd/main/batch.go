@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+// batchItem 批量文件中的一项
+type batchItem struct {
+	Recipient string `json:"recipient"`
+	Chain     string `json:"chain"` // 目标链
+	Token     string `json:"token"` // 代币名称或ID，源链和目标链相同
+	Amount    string `json:"amount"`
+}
+
+// batchReportItem 批量结果报告中的一项
+type batchReportItem struct {
+	Index     int    `json:"index"`
+	Recipient string `json:"recipient"`
+	Chain     string `json:"chain"`
+	Token     string `json:"token"`
+	Amount    string `json:"amount"`
+	Fee       string `json:"fee,omitempty"`
+	RecordID  string `json:"recordId,omitempty"`
+	Status    string `json:"status"`
+	SwapID    string `json:"swapId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// runBatch 执行batch子命令: 从CSV或JSON文件读取批量转账，确认后执行
+// 进度写入状态文件，中断后用相同的参数重新运行即可从中断处继续
+func runBatch(args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	statePath := fs.String("state", "", "进度状态文件(默认为输入文件名加.state.jsonl)")
+	reportPath := fs.String("report", "", "结果报告输出文件(JSON)，默认只打印到终端")
+	concurrency := fs.Int("concurrency", meson.DefaultBatchConcurrency, "同时进行的转账数")
+	yes := fs.Bool("yes", false, "跳过确认提示")
+	positional := parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("用法: batch [参数] <文件.csv|文件.json>")
+	}
+	inputPath := positional[0]
+
+	items, err := readBatchFile(inputPath)
+	if err != nil {
+		return withCode(exitUsage, fmt.Errorf("读取批量文件失败: %w", err))
+	}
+	if len(items) == 0 {
+		return usageErrorf("批量文件中没有转账")
+	}
+
+	// 批量转账的进度单独保存在状态文件中
+	if *statePath == "" {
+		*statePath = inputPath + ".state.jsonl"
+	}
+	g.storePath = *statePath
+
+	ctx := context.Background()
+	bridge, err := g.newBridge()
+	if err != nil {
+		return err
+	}
+	defer g.closeWebhooks()
+	defer bridge.SwapStore().Close()
+	sender, err := g.newSender(ctx, bridge)
+	if err != nil {
+		return err
+	}
+	fromAddr := sender.From().Hex()
+	sourceChain := g.chain()
+
+	// 幂等键由文件内容和序号决定，重新运行时已提交的项不会重复转账
+	batchID, err := fileDigest(inputPath)
+	if err != nil {
+		return fmt.Errorf("读取批量文件失败: %w", err)
+	}
+
+	reqs := make([]*meson.TransferRequest, len(items))
+	report := make([]*batchReportItem, len(items))
+	for i, item := range items {
+		report[i] = &batchReportItem{Index: i, Recipient: item.Recipient, Chain: item.Chain, Token: item.Token, Amount: item.Amount, Status: "pending"}
+		req, err := item.request(fromAddr)
+		if err != nil {
+			return usageErrorf("第%d项无效: %w", i+1, err)
+		}
+		if err := g.registerToken(bridge, req.FromToken); err != nil {
+			return err
+		}
+		req.FromChain = sourceChain
+		req.IdempotencyKey = fmt.Sprintf("batch:%s:%d", batchID, i)
+		reqs[i] = req
+	}
+
+	// 已提交或已结束的项直接计入报告
+	var pending []int
+	for i, req := range reqs {
+		record, err := bridge.FindTransfer(req.IdempotencyKey)
+		if err == nil && (record.Status == meson.StatusSubmitted || record.Status.Final()) {
+			report[i].fill(record)
+			if g.output == outputNDJSON {
+				g.emit(report[i], nil)
+			}
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	fmt.Fprintf(stderr, "批量文件: %s，共%d项，待执行%d项\n", inputPath, len(items), len(pending))
+	fmt.Fprintf(stderr, "发送地址: %s，源链: %s\n", fromAddr, sourceChain)
+	if len(pending) > 0 {
+		summarizeBatch(ctx, bridge, reqs, pending, report)
+		if !*yes && !confirm("确认执行以上转账?") {
+			return errAborted
+		}
+
+		pendingReqs := make([]*meson.TransferRequest, len(pending))
+		for i, index := range pending {
+			pendingReqs[i] = reqs[index]
+		}
+		var mu sync.Mutex
+		bridge.TransferBatch(ctx, sender, pendingReqs, &meson.BatchOptions{
+			Concurrency: *concurrency,
+			OnResult: func(result *meson.BatchResult) {
+				mu.Lock()
+				defer mu.Unlock()
+				item := report[pending[result.Index]]
+				if result.Record != nil {
+					item.fill(result.Record)
+				}
+				if result.Err != nil {
+					item.Status = "failed"
+					item.Error = result.Err.Error()
+				}
+				fmt.Fprintf(stderr, "[%d/%d] %s %s %s: %s\n", item.Index+1, len(items), item.Recipient, item.Amount, item.Token, item.Status)
+				if g.output == outputNDJSON {
+					g.emit(item, nil)
+				}
+			},
+		})
+	}
+
+	// ndjson模式下结果已逐行输出
+	if g.output != outputNDJSON {
+		if err := g.emit(report, func(w io.Writer) { printBatchReport(w, report) }); err != nil {
+			return err
+		}
+	}
+	if *reportPath != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("生成报告失败: %w", err)
+		}
+		if err := os.WriteFile(*reportPath, data, 0o644); err != nil {
+			return fmt.Errorf("写入报告失败: %w", err)
+		}
+		fmt.Fprintf(stderr, "结果报告已写入: %s\n", *reportPath)
+	}
+
+	failed := 0
+	for _, item := range report {
+		if item.Status == "failed" || item.Status == string(meson.StatusCancelled) || item.Status == string(meson.StatusExpired) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return withCode(exitSwapFailed, fmt.Errorf("%d项转账失败", failed))
+	}
+	return nil
+}
+
+// request 转换为转账请求
+func (item *batchItem) request(fromAddr string) (*meson.TransferRequest, error) {
+	if !common.IsHexAddress(item.Recipient) {
+		return nil, fmt.Errorf("无效的接收地址: %s", item.Recipient)
+	}
+	if item.Chain == "" {
+		return nil, fmt.Errorf("缺少目标链")
+	}
+	token, err := parseToken(item.Token)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := meson.ParseMesonAmount(item.Amount)
+	if err != nil {
+		return nil, err
+	}
+	return &meson.TransferRequest{
+		ToChain:     meson.Chain(item.Chain),
+		FromToken:   token,
+		ToToken:     token,
+		Amount:      amount,
+		FromAddress: fromAddr,
+		Recipient:   item.Recipient,
+	}, nil
+}
+
+// fill 用转账记录更新报告项
+func (item *batchReportItem) fill(record *meson.SwapRecord) {
+	item.RecordID = record.ID
+	item.Status = string(record.Status)
+	item.SwapID = record.SwapID
+	item.Error = record.Error
+	if record.Fee != "" {
+		item.Fee = record.Fee
+	}
+}
+
+// readBatchFile 按扩展名读取CSV或JSON批量文件
+// CSV需包含表头recipient,chain,token,amount，JSON为同样字段的对象数组
+func readBatchFile(path string) ([]*batchItem, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var items []*batchItem
+		if err := json.NewDecoder(file).Decode(&items); err != nil {
+			return nil, fmt.Errorf("解析JSON失败: %w", err)
+		}
+		for i, item := range items {
+			if item == nil {
+				return nil, fmt.Errorf("第%d项为空", i+1)
+			}
+		}
+		return items, nil
+	}
+	return readBatchCSV(file)
+}
+
+// readBatchCSV 读取CSV批量文件
+func readBatchCSV(r io.Reader) ([]*batchItem, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取CSV表头失败: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"recipient", "chain", "token", "amount"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV缺少列: %s", name)
+		}
+	}
+
+	var items []*batchItem
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("第%d行: %w", line, err)
+		}
+		items = append(items, &batchItem{
+			Recipient: strings.TrimSpace(record[columns["recipient"]]),
+			Chain:     strings.TrimSpace(record[columns["chain"]]),
+			Token:     strings.TrimSpace(record[columns["token"]]),
+			Amount:    strings.TrimSpace(record[columns["amount"]]),
+		})
+	}
+	return items, nil
+}
+
+// summarizeBatch 查询待执行项的手续费，按代币打印发送金额、预计到账金额和授权交易的gas
+// Meson的手续费从转账金额中扣除，发送金额即为总支出
+func summarizeBatch(ctx context.Context, bridge *meson.Bridge, reqs []*meson.TransferRequest, pending []int, report []*batchReportItem) {
+	var (
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, meson.DefaultBatchConcurrency)
+	)
+	for _, index := range pending {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			req := reqs[index]
+			price, err := bridge.GetPrice(req.Amount, req.FromAddress, req.FromChain, req.ToChain, req.FromToken, req.ToToken)
+			if err != nil {
+				report[index].Error = fmt.Sprintf("询价失败: %v", err)
+				return
+			}
+			report[index].Fee = price.TotalFee
+		}(index)
+	}
+	wg.Wait()
+
+	var tokens []meson.Token
+	first := make(map[meson.Token]*meson.TransferRequest)
+	amounts := make(map[meson.Token]decimal.Decimal)
+	fees := make(map[meson.Token]decimal.Decimal)
+	received := make(map[meson.Token]decimal.Decimal)
+	quoteFailed := 0
+	for _, index := range pending {
+		req := reqs[index]
+		token := req.FromToken
+		if _, ok := first[token]; !ok {
+			tokens = append(tokens, token)
+			first[token] = req
+		}
+		amounts[token] = amounts[token].Add(req.Amount.Decimal())
+		fee, err := decimal.NewFromString(report[index].Fee)
+		if err != nil {
+			quoteFailed++
+			continue
+		}
+		fees[token] = fees[token].Add(fee)
+		received[token] = received[token].Add(req.Amount.Decimal().Sub(fee))
+	}
+
+	fmt.Fprintln(stderr, "\n====== 批量转账汇总 ======")
+	totalGas := decimal.Zero
+	for _, token := range tokens {
+		name := tokenDisplayName(token)
+		fmt.Fprintf(stderr, "%s: 发送 %s，手续费 %s，预计到账 %s\n", name, amounts[token], fees[token], received[token])
+
+		// 批量转账按代币合并授权，按本组总金额预检授权额度
+		approve := *first[token]
+		total, err := meson.NewTokenAmount(amounts[token], meson.MesonDecimals)
+		if err != nil {
+			fmt.Fprintf(stderr, "  授权: 无法预估(%v)\n", err)
+			continue
+		}
+		approve.Amount = total
+		preflight, err := bridge.Preflight(ctx, &approve)
+		switch {
+		case err != nil:
+			fmt.Fprintf(stderr, "  授权: 无法预估(%v)\n", err)
+		case preflight.NeedsApprove:
+			fmt.Fprintf(stderr, "  授权: 需要授权 %s，预计gas %s 原生代币\n", total, preflight.EstimatedGas)
+			totalGas = totalGas.Add(preflight.EstimatedGas)
+		default:
+			fmt.Fprintln(stderr, "  授权: 无需授权")
+		}
+	}
+	fmt.Fprintf(stderr, "预计gas合计: %s 原生代币(跨链交易由中继器提交，只有授权交易需要gas)\n", totalGas)
+	if quoteFailed > 0 {
+		fmt.Fprintf(stderr, "警告: %d项询价失败，未计入手续费和到账金额\n", quoteFailed)
+	}
+	fmt.Fprintln(stderr)
+}
+
+// printBatchReport 打印结果报告
+func printBatchReport(w io.Writer, report []*batchReportItem) {
+	counts := make(map[string]int)
+	fmt.Fprintln(w, "\n====== 批量转账结果 ======")
+	for _, item := range report {
+		counts[item.Status]++
+		line := fmt.Sprintf("%d. %s %s %s -> %s: %s", item.Index+1, item.Amount, item.Token, item.Chain, item.Recipient, item.Status)
+		if item.SwapID != "" {
+			line += " " + item.SwapID
+		}
+		if item.Error != "" {
+			line += " (" + item.Error + ")"
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "合计: %v\n", counts)
+}
+
+// fileDigest 计算文件内容的摘要
+func fileDigest(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8]), nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadBatchCSV(t *testing.T) {
+	items, err := readBatchCSV(strings.NewReader("Amount, recipient,chain,token\n6, 0x1111111111111111111111111111111111111111 ,bnb,merl\n10.5,0x2222222222222222222222222222222222222222,bnb,69\n"))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "0x1111111111111111111111111111111111111111", items[0].Recipient)
+	require.Equal(t, "6", items[0].Amount)
+	require.Equal(t, "69", items[1].Token)
+
+	_, err = readBatchCSV(strings.NewReader("recipient,chain,amount\n"))
+	require.ErrorContains(t, err, "token")
+	_, err = readBatchCSV(strings.NewReader("recipient,chain,token,amount\n0x1,bnb\n"))
+	require.ErrorContains(t, err, "第2行")
+	_, err = readBatchCSV(strings.NewReader(""))
+	require.Error(t, err)
+}
+
+func TestReadBatchFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	items, err := readBatchFile(write("a.json", `[{"recipient": "0x1111111111111111111111111111111111111111", "chain": "bnb", "token": "merl", "amount": "6"}]`))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "bnb", items[0].Chain)
+
+	items, err = readBatchFile(write("b.CSV", "recipient,chain,token,amount\n0x1111111111111111111111111111111111111111,bnb,merl,6\n"))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+
+	_, err = readBatchFile(write("c.json", `[null]`))
+	require.ErrorContains(t, err, "第1项为空")
+	_, err = readBatchFile(write("d.json", `{"recipient": "0x1"}`))
+	require.ErrorContains(t, err, "解析JSON失败")
+	_, err = readBatchFile(filepath.Join(dir, "missing.csv"))
+	require.Error(t, err)
+}
@@ -0,0 +1,684 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+// parseFlags 解析参数，允许参数和位置参数交错出现，返回位置参数
+// "--"之后的参数都作为位置参数
+func parseFlags(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...)
+		}
+		if len(rest) == 0 {
+			return positional
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// quoteOutput quote子命令的输出
+type quoteOutput struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Amount     string `json:"amount"`
+	ServiceFee string `json:"serviceFee"`
+	LpFee      string `json:"lpFee"`
+	TotalFee   string `json:"totalFee"`
+	Received   string `json:"received"`
+}
+
+// runQuote 查询跨链手续费，只访问中继器
+func runQuote(args []string) error {
+	fs := flag.NewFlagSet("quote", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	toChain := fs.String("to-chain", "", "目标链")
+	tokenStr := fs.String("token", "", "代币ID或名称 (如 'merl' 或 '69')")
+	toTokenStr := fs.String("to-token", "", "目标链代币(默认与源链相同)")
+	amountStr := fs.String("amount", "", "跨链金额")
+	from := fs.String("from", "", "发送地址(默认为签名账户地址)")
+	parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if *toChain == "" || *tokenStr == "" || *amountStr == "" {
+		return usageErrorf("缺少必要参数: --to-chain --token --amount")
+	}
+
+	fromToken, toToken, err := parseTokenPair(*tokenStr, *toTokenStr)
+	if err != nil {
+		return err
+	}
+	amount, err := meson.ParseMesonAmount(*amountStr)
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	fromAddr := *from
+	if fromAddr == "" {
+		fromAddr = g.address()
+	}
+
+	price, err := meson.NewBridge().GetPrice(amount, fromAddr, g.chain(), meson.Chain(*toChain), fromToken, toToken)
+	if err != nil {
+		return err
+	}
+	out := &quoteOutput{
+		From:       fmt.Sprintf("%s:%s", g.chain(), fromToken),
+		To:         fmt.Sprintf("%s:%s", *toChain, toToken),
+		Amount:     amount.String(),
+		ServiceFee: price.ServiceFee,
+		LpFee:      price.LpFee,
+		TotalFee:   price.TotalFee,
+	}
+	if totalFee, err := decimal.NewFromString(price.TotalFee); err == nil {
+		out.Received = amount.Decimal().Sub(totalFee).String()
+	}
+	return g.emit(out, func(w io.Writer) {
+		fmt.Fprintf(w, "%s -> %s，金额: %s %s\n", out.From, out.To, out.Amount, tokenDisplayName(fromToken))
+		fmt.Fprintf(w, "服务费: %s\nLP手续费: %s\n总手续费: %s\n预计到账: %s\n", out.ServiceFee, out.LpFee, out.TotalFee, out.Received)
+	})
+}
+
+// approveOutput approve子命令的输出
+type approveOutput struct {
+	Chain  string `json:"chain"`
+	Token  string `json:"token"`
+	Amount string `json:"amount"` // 授权最大值时为"max"
+	TxHash string `json:"txHash"`
+}
+
+// runApprove 授权池合约使用代币，不指定金额时授权最大值
+func runApprove(args []string) error {
+	fs := flag.NewFlagSet("approve", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	tokenStr := fs.String("token", "", "代币ID或名称")
+	amountStr := fs.String("amount", "", "授权金额(默认授权最大值)")
+	yes := fs.Bool("yes", false, "跳过确认提示")
+	parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if *tokenStr == "" {
+		return usageErrorf("缺少必要参数: --token")
+	}
+	token, err := parseToken(*tokenStr)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	bridge, err := g.newBridge()
+	if err != nil {
+		return err
+	}
+	if err := g.registerToken(bridge, token); err != nil {
+		return err
+	}
+	sender, err := g.newSender(ctx, bridge)
+	if err != nil {
+		return err
+	}
+
+	out := &approveOutput{Chain: string(g.chain()), Token: string(token), Amount: "max"}
+	var txData *helpers.TxData
+	if *amountStr == "" {
+		txData, err = bridge.GetApproveData(ctx, sender.From().Hex(), g.chain(), token, g.tokenAddress)
+	} else {
+		// 精度按输入的小数位数确定，换算为最小单位时再按代币精度检查
+		value, parseErr := decimal.NewFromString(*amountStr)
+		if parseErr != nil {
+			return usageErrorf("无效的金额: %s", *amountStr)
+		}
+		decimals := -value.Exponent()
+		if decimals < 0 {
+			decimals = 0
+		}
+		amount, parseErr := meson.NewTokenAmount(value, decimals)
+		if parseErr != nil {
+			return withCode(exitUsage, parseErr)
+		}
+		out.Amount = amount.String()
+		txData, err = bridge.GetApproveAmountData(ctx, g.chain(), token, amount)
+	}
+	if err != nil {
+		return fmt.Errorf("获取Approve数据失败: %w", err)
+	}
+
+	if !*yes {
+		pool, _ := bridge.PoolAddress(g.chain())
+		fmt.Fprintf(stderr, "链: %s\n代币: %s %s\n授权给池合约: %s\n授权金额: %s\n", out.Chain, tokenDisplayName(token), txData.To.Hex(), pool.Hex(), out.Amount)
+		if gas, err := estimateGasCost(ctx, bridge, sender.From(), txData); err == nil {
+			fmt.Fprintf(stderr, "预计gas: %s 原生代币\n", gas)
+		}
+		if !confirm("确认发送授权交易?") {
+			return errAborted
+		}
+	}
+
+	result, err := sender.Send(ctx, txData)
+	if err != nil {
+		return fmt.Errorf("发送Approve交易失败: %w", err)
+	}
+	out.TxHash = result.Hash.Hex()
+	return g.emit(out, func(w io.Writer) {
+		fmt.Fprintf(w, "已授权%s链上的%s，金额: %s\n交易哈希: %s\n", out.Chain, tokenDisplayName(token), out.Amount, out.TxHash)
+	})
+}
+
+// allowanceOutput allowance子命令的输出
+type allowanceOutput struct {
+	Chain     string `json:"chain"`
+	Token     string `json:"token"`
+	Owner     string `json:"owner"`
+	Allowance string `json:"allowance"`
+}
+
+// runAllowance 查询对池合约的授权额度
+func runAllowance(args []string) error {
+	fs := flag.NewFlagSet("allowance", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	tokenStr := fs.String("token", "", "代币ID或名称")
+	owner := fs.String("owner", "", "代币持有地址(默认为签名账户地址)")
+	parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if *tokenStr == "" {
+		return usageErrorf("缺少必要参数: --token")
+	}
+	token, err := parseToken(*tokenStr)
+	if err != nil {
+		return err
+	}
+	if *owner == "" {
+		*owner = g.address()
+	}
+	if *owner == "" {
+		return usageErrorf("缺少必要参数: --owner 或 --key")
+	}
+
+	bridge, err := g.newBridge()
+	if err != nil {
+		return err
+	}
+	if err := g.registerToken(bridge, token); err != nil {
+		return err
+	}
+	allowance, err := bridge.GetAllowance(context.Background(), g.chain(), token, *owner)
+	if err != nil {
+		return err
+	}
+	out := &allowanceOutput{Chain: string(g.chain()), Token: string(token), Owner: *owner, Allowance: allowance.String()}
+	return g.emit(out, func(w io.Writer) {
+		fmt.Fprintf(w, "%s 在%s链上对池合约的%s授权额度: %s\n", out.Owner, out.Chain, tokenDisplayName(token), out.Allowance)
+	})
+}
+
+// transferOutput bridge子命令的输出
+type transferOutput struct {
+	ID            string `json:"id"` // 本地转账记录ID
+	SwapID        string `json:"swapId,omitempty"`
+	Status        string `json:"status"`
+	Phase         string `json:"phase,omitempty"` // 中继器报告的最新阶段
+	FromChain     string `json:"fromChain"`
+	ToChain       string `json:"toChain"`
+	FromToken     string `json:"fromToken"`
+	ToToken       string `json:"toToken"`
+	Amount        string `json:"amount"`
+	FromAddress   string `json:"fromAddress"`
+	Recipient     string `json:"recipient"`
+	Fee           string `json:"fee,omitempty"`
+	ApproveTxHash string `json:"approveTxHash,omitempty"`
+	Encoded       string `json:"encoded,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// newTransferOutput 由转账记录生成输出
+func newTransferOutput(record *meson.SwapRecord) *transferOutput {
+	return &transferOutput{
+		ID:            record.ID,
+		SwapID:        record.SwapID,
+		Status:        string(record.Status),
+		Phase:         string(record.Phase),
+		FromChain:     string(record.FromChain),
+		ToChain:       string(record.ToChain),
+		FromToken:     string(record.FromToken),
+		ToToken:       string(record.ToToken),
+		Amount:        record.Amount,
+		FromAddress:   record.FromAddress,
+		Recipient:     record.Recipient,
+		Fee:           record.Fee,
+		ApproveTxHash: record.ApproveTxHash,
+		Encoded:       record.Encoded,
+		Error:         record.Error,
+	}
+}
+
+// runBridge 执行完整跨链转账，参数与旧版命令行兼容
+func runBridge(args []string) error {
+	fs := flag.NewFlagSet("bridge", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	amountStr := fs.String("amount", "0.0001", "跨链金额")
+	toChain := fs.String("to-chain", string(meson.ChainZksync), "目标链")
+	recipient := fs.String("recipient", "", "接收地址(默认与发送地址相同)")
+	tokenStr := fs.String("token", "", "代币ID或名称 (如 'merl' 或 '69')")
+	toTokenStr := fs.String("to-token", "", "目标链代币(默认与源链相同)")
+	skipApprove := fs.Bool("skip-approve", false, "跳过approve步骤")
+	wait := fs.Bool("wait", false, "等待跨链完成")
+	yes := fs.Bool("yes", false, "跳过确认提示")
+	force := fs.Bool("force", false, "预检发现问题时仍然执行")
+	parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if *tokenStr == "" {
+		return usageErrorf("缺少必要参数: --token")
+	}
+
+	fromToken, toToken, err := parseTokenPair(*tokenStr, *toTokenStr)
+	if err != nil {
+		return err
+	}
+	amount, err := meson.ParseMesonAmount(*amountStr)
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+
+	ctx := context.Background()
+	bridge, err := g.newBridge()
+	if err != nil {
+		return err
+	}
+	defer g.closeWebhooks()
+	if err := g.registerToken(bridge, fromToken); err != nil {
+		return err
+	}
+	sender, err := g.newSender(ctx, bridge)
+	if err != nil {
+		return err
+	}
+
+	req := &meson.TransferRequest{
+		FromChain:   g.chain(),
+		ToChain:     meson.Chain(*toChain),
+		FromToken:   fromToken,
+		ToToken:     toToken,
+		Amount:      amount,
+		FromAddress: sender.From().Hex(),
+		Recipient:   *recipient,
+		SkipApprove: *skipApprove,
+		Force:       *force,
+	}
+	// 签名的即为预览时编码并向用户展示的交易
+	preview, err := bridge.PreviewTransfer(ctx, req, nil)
+	if err != nil {
+		return err
+	}
+	req.Prepared = preview
+	if !*yes {
+		fmt.Fprint(stderr, preview.Describe())
+		if len(preview.Problems) > 0 && !*force {
+			return fmt.Errorf("%w，确认无误后可使用--force继续", meson.ErrPreflightFailed)
+		}
+		if !confirm("确认执行以上转账?") {
+			return errAborted
+		}
+	}
+
+	record, err := bridge.Transfer(ctx, sender, req)
+	if err != nil {
+		return err
+	}
+	if *wait {
+		if record, err = bridge.WatchTransfer(ctx, sender, record.ID); err != nil {
+			return err
+		}
+	}
+	err = g.emit(newTransferOutput(record), func(w io.Writer) {
+		fmt.Fprintf(w, "跨链交易已提交, ID: %s\n", record.SwapID)
+		if record.ApproveTxHash != "" {
+			fmt.Fprintf(w, "Approve交易哈希: %s\n", record.ApproveTxHash)
+		}
+		fmt.Fprintf(w, "当前状态: %s\n", record.Status)
+		if !*wait && record.Status == meson.StatusSubmitted {
+			fmt.Fprintf(w, "可使用以下命令等待完成: main watch %s\n", record.SwapID)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if record.Status.Final() && record.Status != meson.StatusCompleted {
+		return withCode(exitSwapFailed, fmt.Errorf("跨链未完成，状态: %s", record.Status))
+	}
+	return nil
+}
+
+// statusOutput status子命令的输出
+type statusOutput struct {
+	SwapID string          `json:"swapId"`
+	Phase  meson.SwapPhase `json:"phase"`
+	Status map[string]any  `json:"status"`
+}
+
+// runStatus 查询中继器上的跨链状态
+func runStatus(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	positional := parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("用法: status <swapId>")
+	}
+
+	status, err := meson.NewBridge().GetSwapStatus(positional[0])
+	if err != nil {
+		return err
+	}
+	out := &statusOutput{SwapID: positional[0], Phase: meson.RelayerPhase(status), Status: status}
+	return g.emit(out, func(w io.Writer) {
+		fmt.Fprintf(w, "跨链ID: %s\n当前阶段: %s\n", out.SwapID, out.Phase)
+		keys := make([]string, 0, len(status))
+		for key := range status {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Fprintf(w, "  %s: %v\n", key, status[key])
+		}
+	})
+}
+
+// watchEvent watch子命令在ndjson模式下每次阶段变化输出的一行
+type watchEvent struct {
+	SwapID string          `json:"swapId"`
+	Phase  meson.SwapPhase `json:"phase"`
+	Time   time.Time       `json:"time"`
+	Final  bool            `json:"final"` // 是否为最后一行
+}
+
+// runWatch 轮询跨链状态，直到目标链释放、执行或取消
+// ndjson模式下每次阶段变化输出一行，json模式下只输出最终状态
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	interval := fs.Duration("interval", meson.WatchInterval, "查询间隔")
+	timeout := fs.Duration("timeout", 0, "最长等待时间(默认不限制)")
+	positional := parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("用法: watch <swapId>")
+	}
+	swapId := positional[0]
+
+	ctx := context.Background()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	bridge := meson.NewBridge()
+	bridge.SetLogOutput(stderr)
+	last := meson.PhaseUnknown
+	for {
+		status, err := bridge.GetSwapStatus(swapId)
+		if err != nil {
+			fmt.Fprintf(stderr, "查询跨链状态失败: %v\n", err)
+		} else if phase := meson.RelayerPhase(status); phase != last {
+			last = phase
+			done := phase == meson.PhaseReleased || phase.Final()
+			switch g.output {
+			case outputText:
+				fmt.Fprintf(stdout, "%s 阶段: %s\n", time.Now().Format(time.DateTime), phase)
+			case outputNDJSON:
+				if err := g.emit(&watchEvent{SwapID: swapId, Phase: phase, Time: time.Now(), Final: done}, nil); err != nil {
+					return err
+				}
+			}
+			if done {
+				if g.output == outputJSON {
+					if err := g.emit(&statusOutput{SwapID: swapId, Phase: phase, Status: status}, nil); err != nil {
+						return err
+					}
+				}
+				if phase == meson.PhaseCancelled {
+					return withCode(exitSwapFailed, fmt.Errorf("跨链已取消"))
+				}
+				if g.output == outputText {
+					fmt.Fprintln(stdout, "跨链已结束")
+				}
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return withCode(exitTimeout, fmt.Errorf("等待超时，最后阶段: %s", last))
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// cancelOutput cancel子命令的输出
+type cancelOutput struct {
+	Encoded   string    `json:"encoded"`
+	Initiator string    `json:"initiator"`
+	Amount    string    `json:"amount"`
+	ExpiredAt time.Time `json:"expiredAt"`
+	TxHash    string    `json:"txHash"`
+}
+
+// runCancel 取消源链上已过期的跨链交易
+func runCancel(args []string) error {
+	fs := flag.NewFlagSet("cancel", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	positional := parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("用法: cancel <swapId|encoded>")
+	}
+
+	ctx := context.Background()
+	bridge, err := g.newBridge()
+	if err != nil {
+		return err
+	}
+	sender, err := g.newSender(ctx, bridge)
+	if err != nil {
+		return err
+	}
+	result, err := bridge.CancelExpiredSwap(ctx, sender, g.chain(), positional[0])
+	if err != nil {
+		return err
+	}
+	out := &cancelOutput{
+		Encoded:   result.Encoded,
+		Initiator: result.Initiator.Hex(),
+		Amount:    result.Amount.String(),
+		ExpiredAt: result.ExpiredAt,
+		TxHash:    result.Tx.Hash.Hex(),
+	}
+	return g.emit(out, func(w io.Writer) {
+		fmt.Fprintf(w, "已取消跨链交易 %s\n退回%s: %s\n交易哈希: %s\n", out.Encoded, out.Initiator, out.Amount, out.TxHash)
+	})
+}
+
+// chainOutput chains子命令输出的一项
+type chainOutput struct {
+	Chain string `json:"chain"`
+	RPC   string `json:"rpc,omitempty"`
+	Pool  string `json:"pool,omitempty"`
+	Code  uint16 `json:"code,omitempty"`
+}
+
+// runChains 列出内置和配置文件中的链
+func runChains(args []string) error {
+	fs := flag.NewFlagSet("chains", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	chains := map[string]*chainOutput{}
+	get := func(name string) *chainOutput {
+		if chains[name] == nil {
+			chains[name] = &chainOutput{Chain: name}
+		}
+		return chains[name]
+	}
+	for _, chain := range []meson.Chain{meson.ChainMerlin, meson.ChainZksync, meson.ChainDuckchain} {
+		get(string(chain))
+	}
+	get(string(meson.ChainMerlin)).Pool = meson.PoolAddress
+	for code, chain := range meson.ChainCodeMap {
+		get(string(chain)).Code = code
+	}
+	for name, cfg := range g.config.Chains {
+		out := get(name)
+		out.RPC = cfg.RPC
+		if cfg.Pool != "" {
+			out.Pool = cfg.Pool
+		}
+		if cfg.Code != 0 {
+			out.Code = cfg.Code
+		}
+	}
+
+	list := make([]*chainOutput, 0, len(chains))
+	for _, out := range chains {
+		list = append(list, out)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Chain < list[j].Chain })
+	return g.emit(list, func(w io.Writer) {
+		for _, out := range list {
+			fmt.Fprintf(w, "%-10s", out.Chain)
+			if out.Code != 0 {
+				fmt.Fprintf(w, " 编号: 0x%04x", out.Code)
+			}
+			if out.Pool != "" {
+				fmt.Fprintf(w, " 池合约: %s", out.Pool)
+			}
+			if out.RPC != "" {
+				fmt.Fprintf(w, " RPC: %s", out.RPC)
+			}
+			fmt.Fprintln(w)
+		}
+	})
+}
+
+// tokenOutput tokens子命令输出的一项
+type tokenOutput struct {
+	ID        string            `json:"id"`
+	Names     []string          `json:"names"`
+	Addresses map[string]string `json:"addresses"` // 链到代币地址
+}
+
+// runTokens 列出代币名称、ID以及预设和配置文件中的地址
+func runTokens(args []string) error {
+	fs := flag.NewFlagSet("tokens", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	tokens := map[meson.Token]*tokenOutput{}
+	get := func(token meson.Token) *tokenOutput {
+		if tokens[token] == nil {
+			tokens[token] = &tokenOutput{ID: string(token), Names: []string{}, Addresses: map[string]string{}}
+		}
+		return tokens[token]
+	}
+	for name, id := range tokenNameToID {
+		out := get(meson.Token(strconv.FormatInt(id, 10)))
+		out.Names = append(out.Names, name)
+	}
+	for key, addr := range meson.TokenAddressMap {
+		get(key.Token).Addresses[string(key.Chain)] = addr
+	}
+	for chain, cfg := range g.config.Chains {
+		for tokenName, addr := range cfg.Tokens {
+			token, err := parseToken(tokenName)
+			if err != nil {
+				return err
+			}
+			get(token).Addresses[chain] = addr
+		}
+	}
+
+	list := make([]*tokenOutput, 0, len(tokens))
+	for _, out := range tokens {
+		sort.Strings(out.Names)
+		list = append(list, out)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return g.emit(list, func(w io.Writer) {
+		for _, out := range list {
+			fmt.Fprintf(w, "%-4s %s\n", out.ID, strings.Join(out.Names, ", "))
+			chains := make([]string, 0, len(out.Addresses))
+			for chain := range out.Addresses {
+				chains = append(chains, chain)
+			}
+			sort.Strings(chains)
+			for _, chain := range chains {
+				fmt.Fprintf(w, "     %s: %s\n", chain, out.Addresses[chain])
+			}
+		}
+	})
+}
+
+// estimateGasCost 估算交易消耗的原生代币
+func estimateGasCost(ctx context.Context, bridge *meson.Bridge, from common.Address, txData *helpers.TxData) (decimal.Decimal, error) {
+	gas, err := helpers.EstimateGas(ctx, bridge.EthClient(), from, txData)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	gasPrice, err := bridge.EthClient().SuggestGasPrice(ctx)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	cost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas))
+	return decimal.NewFromBigInt(cost, -18), nil
+}
+
+// parseTokenPair 解析源链和目标链代币，目标链代币为空时与源链相同
+func parseTokenPair(fromStr, toStr string) (meson.Token, meson.Token, error) {
+	fromToken, err := parseToken(fromStr)
+	if err != nil {
+		return "", "", err
+	}
+	if toStr == "" {
+		return fromToken, fromToken, nil
+	}
+	toToken, err := parseToken(toStr)
+	if err != nil {
+		return "", "", err
+	}
+	return fromToken, toToken, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		positional []string
+		amount     string
+		yes        bool
+	}{
+		{"flags only", []string{"--amount", "6", "--yes"}, nil, "6", true},
+		{"positional first", []string{"0xabc", "--amount=6"}, []string{"0xabc"}, "6", false},
+		{"interleaved", []string{"a.csv", "--yes", "b.csv", "--amount", "1"}, []string{"a.csv", "b.csv"}, "1", true},
+		{"terminator", []string{"--amount", "2", "--", "x", "--yes"}, []string{"x", "--yes"}, "2", false},
+		{"terminator after positional", []string{"x", "--", "-y"}, []string{"x", "-y"}, "", false},
+		{"empty", nil, nil, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			amount := fs.String("amount", "", "")
+			yes := fs.Bool("yes", false, "")
+			require.Equal(t, tt.positional, parseFlags(fs, tt.args))
+			require.Equal(t, tt.amount, *amount)
+			require.Equal(t, tt.yes, *yes)
+		})
+	}
+}
+
+func TestParseToken(t *testing.T) {
+	tests := []struct {
+		in      string
+		token   meson.Token
+		display string
+	}{
+		{"merl", "69", "MERL(69)"},
+		{"MBTC", "67", "MBTC(67)"},
+		{"btc", "67", "MBTC(67)"},
+		{"42", "42", "Token(42)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			token, err := parseToken(tt.in)
+			require.NoError(t, err)
+			require.Equal(t, tt.token, token)
+			require.Equal(t, tt.display, tokenDisplayName(token))
+		})
+	}
+
+	_, err := parseToken("doge")
+	require.Error(t, err)
+	require.Equal(t, exitUsage, exitCode(err))
+}
+
+func TestGlobalOptions_ValidateOutput(t *testing.T) {
+	defer func() { outputFormat = outputText }()
+
+	for _, format := range []string{outputText, outputJSON, outputNDJSON} {
+		g := &globalOptions{output: format}
+		require.NoError(t, g.validate())
+		require.Equal(t, format, outputFormat)
+	}
+
+	g := &globalOptions{output: "yaml"}
+	err := g.validate()
+	require.Error(t, err)
+	require.Equal(t, exitUsage, exitCode(err))
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+	"github.com/mer-coder/meson-bridge/pkg/webhook"
+)
+
+// cliConfig 配置文件，JSON格式
+type cliConfig struct {
+	Chain  string                  `json:"chain"`  // 默认源链
+	Store  string                  `json:"store"`  // 转账记录文件(JSON-lines)
+	Chains map[string]*chainConfig `json:"chains"` // 按链标识配置
+
+	APITokens []string `json:"apiTokens,omitempty"` // serve子命令允许访问的bearer token
+
+	Webhooks   []*webhook.Endpoint `json:"webhooks,omitempty"`   // 转账阶段变化时通知的地址
+	DeadLetter string              `json:"deadLetter,omitempty"` // 投递失败的通知(JSON-lines)，默认为webhook-dead-letters.jsonl
+}
+
+// defaultDeadLetter 默认的通知死信日志文件
+const defaultDeadLetter = "webhook-dead-letters.jsonl"
+
+// webhookCloseTimeout 子命令返回前等待通知投递的最长时间
+const webhookCloseTimeout = 30 * time.Second
+
+// chainConfig 单条链的配置
+type chainConfig struct {
+	RPC    string            `json:"rpc"`
+	Pool   string            `json:"pool,omitempty"`
+	Code   uint16            `json:"code,omitempty"`   // encoded中的链编号(SLIP-44)
+	Tokens map[string]string `json:"tokens,omitempty"` // 代币名称或ID到地址
+}
+
+// globalOptions 所有子命令共用的参数
+type globalOptions struct {
+	configPath   string
+	rpcURL       string
+	fromChain    string
+	privateKey   string
+	tokenAddress string
+	poolAddress  string
+	storePath    string
+	output       string
+
+	config   *cliConfig
+	key      *ecdsa.PrivateKey
+	webhooks *webhook.Dispatcher
+}
+
+// addGlobalFlags 向子命令的参数集合添加共用参数
+func addGlobalFlags(fs *flag.FlagSet) *globalOptions {
+	g := &globalOptions{}
+	fs.StringVar(&g.configPath, "config", os.Getenv("MESON_CONFIG"), "配置文件(JSON)，也可通过MESON_CONFIG环境变量指定")
+	fs.StringVar(&g.rpcURL, "rpc", "", "源链RPC URL(优先于配置文件)")
+	fs.StringVar(&g.fromChain, "from-chain", "", "源链(默认取配置文件，否则为merlin)")
+	fs.StringVar(&g.privateKey, "key", "", "签名私钥(16进制)，也可通过PRIVATE_KEY环境变量指定")
+	fs.StringVar(&g.tokenAddress, "token-address", "", "源链上代币地址(优先于预设地址)")
+	fs.StringVar(&g.poolAddress, "pool-address", "", "源链上池合约地址")
+	fs.StringVar(&g.storePath, "store", "", "转账记录文件(默认取配置文件，否则只保存在内存中)")
+	fs.StringVar(&g.output, "output", outputText, "输出格式: text、json或ndjson")
+	return g
+}
+
+// validate 检查共用参数并加载配置文件
+func (g *globalOptions) validate() error {
+	switch g.output {
+	case outputText, outputJSON, outputNDJSON:
+		outputFormat = g.output
+	default:
+		return usageErrorf("不支持的输出格式: %s", g.output)
+	}
+
+	g.config = &cliConfig{}
+	if g.configPath != "" {
+		data, err := os.ReadFile(g.configPath)
+		if err != nil {
+			return withCode(exitConfig, fmt.Errorf("读取配置文件失败: %w", err))
+		}
+		if err := json.Unmarshal(data, g.config); err != nil {
+			return withCode(exitConfig, fmt.Errorf("解析配置文件失败: %w", err))
+		}
+	}
+	if g.fromChain == "" {
+		g.fromChain = g.config.Chain
+	}
+	if g.fromChain == "" {
+		g.fromChain = string(meson.ChainMerlin)
+	}
+	if g.storePath == "" {
+		g.storePath = g.config.Store
+	}
+	if g.privateKey == "" {
+		g.privateKey = os.Getenv("PRIVATE_KEY")
+	}
+	if g.config.DeadLetter == "" {
+		g.config.DeadLetter = defaultDeadLetter
+	}
+	return nil
+}
+
+// chain 返回源链
+func (g *globalOptions) chain() meson.Chain {
+	return meson.Chain(g.fromChain)
+}
+
+// newBridge 按参数和配置文件创建Bridge: 连接源链和配置中的其他链，注册池地址、代币地址和链编号
+func (g *globalOptions) newBridge() (*meson.Bridge, error) {
+	bridge, err := g.configureBridge()
+	return bridge, withCode(exitConfig, err)
+}
+
+// configureBridge 创建Bridge，错误均视为配置错误
+func (g *globalOptions) configureBridge() (*meson.Bridge, error) {
+	bridge := meson.NewBridge()
+	bridge.SetLogOutput(stderr)
+	source := g.chain()
+
+	rpcURL := g.rpcURL
+	if rpcURL == "" {
+		if cfg := g.config.Chains[g.fromChain]; cfg != nil {
+			rpcURL = cfg.RPC
+		}
+	}
+	if rpcURL == "" {
+		return nil, fmt.Errorf("缺少源链%s的RPC，请通过--rpc或配置文件指定", source)
+	}
+	if err := bridge.InitEthClient(rpcURL, source); err != nil {
+		return nil, err
+	}
+
+	for name, cfg := range g.config.Chains {
+		chain := meson.Chain(name)
+		if chain != source && cfg.RPC != "" {
+			if err := bridge.AddChainClient(cfg.RPC, chain); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.Pool != "" {
+			if err := bridge.RegisterPoolAddress(chain, cfg.Pool); err != nil {
+				return nil, err
+			}
+		}
+		if cfg.Code != 0 {
+			bridge.RegisterChainCode(chain, cfg.Code)
+		}
+		for tokenName, addr := range cfg.Tokens {
+			token, err := parseToken(tokenName)
+			if err != nil {
+				return nil, err
+			}
+			if err := bridge.RegisterTokenAddress(chain, token, addr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if g.poolAddress != "" {
+		if err := bridge.RegisterPoolAddress(source, g.poolAddress); err != nil {
+			return nil, err
+		}
+	}
+
+	if g.storePath != "" {
+		store, err := meson.NewFileStore(g.storePath)
+		if err != nil {
+			return nil, err
+		}
+		bridge.SetSwapStore(store)
+	}
+	if len(g.config.Webhooks) > 0 {
+		g.webhooks = webhook.NewDispatcher(g.config.Webhooks, g.config.DeadLetter)
+		g.webhooks.Log = stderr
+		g.webhooks.Attach(bridge)
+	}
+	return bridge, nil
+}
+
+// closeWebhooks 等待进行中的通知投递结束，子命令返回前调用
+// 超过webhookCloseTimeout仍未投递成功的通知写入死信日志，之后可以通过redeliver重新投递
+func (g *globalOptions) closeWebhooks() {
+	if g.webhooks != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookCloseTimeout)
+		defer cancel()
+		g.webhooks.Close(ctx)
+	}
+}
+
+// pool 返回源链池合约地址: --pool-address优先，其次为配置文件，默认为Meson池合约
+func (g *globalOptions) pool() common.Address {
+	if g.poolAddress != "" {
+		return common.HexToAddress(g.poolAddress)
+	}
+	if cfg := g.config.Chains[g.fromChain]; cfg != nil && cfg.Pool != "" {
+		return common.HexToAddress(cfg.Pool)
+	}
+	return common.HexToAddress(meson.PoolAddress)
+}
+
+// registerToken 用--token-address注册源链代币地址
+func (g *globalOptions) registerToken(bridge *meson.Bridge, token meson.Token) error {
+	if g.tokenAddress == "" {
+		return nil
+	}
+	return withCode(exitConfig, bridge.RegisterTokenAddress(g.chain(), token, g.tokenAddress))
+}
+
+// signer 解析签名私钥
+func (g *globalOptions) signer() (*ecdsa.PrivateKey, error) {
+	if g.key != nil {
+		return g.key, nil
+	}
+	if g.privateKey == "" {
+		return nil, withCode(exitConfig, fmt.Errorf("缺少签名私钥，请通过--key或PRIVATE_KEY环境变量指定"))
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(g.privateKey, "0x"))
+	if err != nil {
+		return nil, withCode(exitConfig, fmt.Errorf("无效的私钥: %w", err))
+	}
+	g.key = key
+	return key, nil
+}
+
+// address 返回签名账户地址，未提供私钥时返回空字符串
+func (g *globalOptions) address() string {
+	key, err := g.signer()
+	if err != nil {
+		return ""
+	}
+	return crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
+
+// newSender 创建源链的交易发送器
+func (g *globalOptions) newSender(ctx context.Context, bridge *meson.Bridge) (*helpers.Sender, error) {
+	key, err := g.signer()
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := bridge.EthClient().ChainID(ctx)
+	if err != nil {
+		return nil, withCode(exitChain, fmt.Errorf("获取链ID失败: %w", err))
+	}
+	sender := helpers.NewSender(bridge.EthClient(), chainID, key)
+	sender.Log = stderr
+	return sender, nil
+}
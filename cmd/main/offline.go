@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+// offlineOutput export和sign子命令的输出
+type offlineOutput struct {
+	File        string    `json:"file"`
+	FromAddress string    `json:"fromAddress"`
+	Approve     bool      `json:"approve"` // 是否包含approve交易
+	Signed      bool      `json:"signed"`
+	Encoded     string    `json:"encoded"`
+	SigningHash string    `json:"signingHash"`
+	ExpireAt    time.Time `json:"expireAt"`
+}
+
+// newOfflineOutput 由离线签名文件生成输出
+func newOfflineOutput(path string, r *meson.OfflineRequest) *offlineOutput {
+	return &offlineOutput{
+		File:        path,
+		FromAddress: r.FromAddress,
+		Approve:     r.Approve != nil,
+		Signed:      r.Signed(),
+		Encoded:     r.Swap.Encoded,
+		SigningHash: r.Swap.SigningHash,
+		ExpireAt:    r.Swap.ExpireAt,
+	}
+}
+
+// runExport 导出待离线签名的approve交易和跨链交易，不需要私钥
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	amountStr := fs.String("amount", "", "跨链金额")
+	toChain := fs.String("to-chain", "", "目标链")
+	recipient := fs.String("recipient", "", "接收地址(默认与发送地址相同)")
+	tokenStr := fs.String("token", "", "代币ID或名称 (如 'merl' 或 '69')")
+	toTokenStr := fs.String("to-token", "", "目标链代币(默认与源链相同)")
+	from := fs.String("from", "", "发送地址(冷钱包地址)")
+	skipApprove := fs.Bool("skip-approve", false, "不检查授权额度，不导出approve交易")
+	out := fs.String("out", "", "离线签名文件")
+	parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if *amountStr == "" || *toChain == "" || *tokenStr == "" || *from == "" || *out == "" {
+		return usageErrorf("缺少必要参数: --amount --to-chain --token --from --out")
+	}
+
+	fromToken, toToken, err := parseTokenPair(*tokenStr, *toTokenStr)
+	if err != nil {
+		return err
+	}
+	amount, err := meson.ParseMesonAmount(*amountStr)
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+
+	bridge, err := g.newBridge()
+	if err != nil {
+		return err
+	}
+	if err := g.registerToken(bridge, fromToken); err != nil {
+		return err
+	}
+	r, err := bridge.ExportOffline(context.Background(), &meson.TransferRequest{
+		FromChain:   g.chain(),
+		ToChain:     meson.Chain(*toChain),
+		FromToken:   fromToken,
+		ToToken:     toToken,
+		Amount:      amount,
+		FromAddress: *from,
+		Recipient:   *recipient,
+		SkipApprove: *skipApprove,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if err := r.WriteFile(*out); err != nil {
+		return err
+	}
+	return g.emit(newOfflineOutput(*out, r), func(w io.Writer) {
+		fmt.Fprintf(w, "离线签名文件已写入: %s\n", *out)
+		if r.Approve != nil {
+			fmt.Fprintf(w, "包含approve交易，nonce: %d\n", r.Approve.Nonce)
+		}
+		fmt.Fprintf(w, "请在%s之前完成签名和提交: main sign %s，然后 main submit %s\n", r.Swap.ExpireAt.Format(time.DateTime), *out, *out)
+	})
+}
+
+// runSign 在离线主机上签名离线签名文件，只需要私钥，不访问网络
+func runSign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	out := fs.String("out", "", "签名后的文件(默认覆盖输入文件)")
+	yes := fs.Bool("yes", false, "跳过确认提示")
+	positional := parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("用法: sign [参数] <离线签名文件>")
+	}
+	path := positional[0]
+	if *out == "" {
+		*out = path
+	}
+
+	r, err := meson.ReadOfflineRequest(path)
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	key, err := g.signer()
+	if err != nil {
+		return err
+	}
+	// 展示前先核对文件，确保摘要中的金额、过期时间和授权对象与实际签名的内容一致
+	pool := g.pool()
+	approve, err := meson.VerifyOffline(r, pool)
+	if err != nil {
+		return withCode(exitUsage, fmt.Errorf("离线签名文件校验失败: %w", err))
+	}
+	if !*yes {
+		fmt.Fprint(stderr, r.Swap.Describe())
+		if approve != nil {
+			fmt.Fprintf(stderr, "Approve交易: 代币合约 %s，授权给 %s，额度 %s(最小单位)，链ID %s，nonce %d\n",
+				approve.Token.Hex(), approve.Spender.Hex(), approve.Amount, r.Approve.ChainID, r.Approve.Nonce)
+		}
+		if !confirm("确认签名以上转账?") {
+			return errAborted
+		}
+	}
+	if err := meson.SignOffline(r, key, pool); err != nil {
+		return err
+	}
+	if err := r.WriteFile(*out); err != nil {
+		return err
+	}
+	return g.emit(newOfflineOutput(*out, r), func(w io.Writer) {
+		swap := r.Swap
+		fmt.Fprintf(w, "已签名: %s %s:%s -> %s:%s，接收地址: %s\n", swap.Amount, swap.FromChain, swap.FromToken, swap.ToChain, swap.ToToken, swap.Recipient)
+		if r.Approve != nil {
+			fmt.Fprintf(w, "已签名approve交易，代币合约: %s，nonce: %d\n", r.Approve.To, r.Approve.Nonce)
+		}
+		fmt.Fprintf(w, "签名文件已写入: %s\n", *out)
+	})
+}
+
+// runSubmit 提交已签名的离线签名文件: 广播approve交易并将跨链交易提交到中继器
+func runSubmit(args []string) error {
+	fs := flag.NewFlagSet("submit", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	yes := fs.Bool("yes", false, "跳过确认提示")
+	positional := parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageErrorf("用法: submit [参数] <离线签名文件>")
+	}
+
+	r, err := meson.ReadOfflineRequest(positional[0])
+	if err != nil {
+		return withCode(exitUsage, err)
+	}
+	if !r.Signed() {
+		return usageErrorf("离线签名文件尚未签名，请先执行 main sign %s", positional[0])
+	}
+	if !*yes {
+		fmt.Fprint(stderr, r.Swap.Describe())
+		if !confirm("确认提交以上转账?") {
+			return errAborted
+		}
+	}
+	bridge, err := g.newBridge()
+	if err != nil {
+		return err
+	}
+	defer bridge.SwapStore().Close()
+
+	record, err := bridge.SubmitOffline(context.Background(), r)
+	if err != nil {
+		return err
+	}
+	return g.emit(newTransferOutput(record), func(w io.Writer) {
+		fmt.Fprintf(w, "跨链交易已提交, ID: %s\n", record.SwapID)
+		if record.ApproveTxHash != "" {
+			fmt.Fprintf(w, "Approve交易哈希: %s\n", record.ApproveTxHash)
+		}
+		fmt.Fprintf(w, "可使用以下命令等待完成: main watch %s\n", record.SwapID)
+	})
+}
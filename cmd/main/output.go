@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+// 退出码，按失败类别区分
+const (
+	exitOK          = 0
+	exitError       = 1 // 未分类的错误
+	exitUsage       = 2 // 参数错误
+	exitConfig      = 3 // 配置文件、私钥或RPC配置错误
+	exitRelayer     = 4 // 中继器请求失败
+	exitChain       = 5 // 链上交易失败或RPC错误
+	exitFeeExceeded = 6 // 手续费超过上限
+	exitSwapFailed  = 7 // 跨链失败、被取消或已过期
+	exitTimeout     = 8 // 等待超时
+	exitAborted     = 9 // 用户未确认
+)
+
+// errorClasses 退出码对应的错误类别，JSON输出中使用
+var errorClasses = map[int]string{
+	exitError:       "error",
+	exitUsage:       "usage",
+	exitConfig:      "config",
+	exitRelayer:     "relayer",
+	exitChain:       "chain",
+	exitFeeExceeded: "fee_exceeded",
+	exitSwapFailed:  "swap_failed",
+	exitTimeout:     "timeout",
+	exitAborted:     "aborted",
+}
+
+// errAborted 用户在确认提示中取消
+var errAborted = &cliError{code: exitAborted, err: errors.New("已取消")}
+
+// 输出格式
+const (
+	outputText   = "text"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson" // 每行一个JSON对象，watch等命令按事件逐行输出
+)
+
+var (
+	// stdout 命令结果的输出，进度和日志写入stderr
+	stdout io.Writer = os.Stdout
+	// stderr 错误信息、进度和日志的输出，库的日志也通过SetLogOutput等指向这里
+	stderr io.Writer = os.Stderr
+	// outputFormat 当前子命令的输出格式，validate时设置
+	outputFormat = outputText
+)
+
+// cliError 带退出码的错误
+type cliError struct {
+	code int
+	err  error
+}
+
+func (e *cliError) Error() string {
+	return e.err.Error()
+}
+
+func (e *cliError) Unwrap() error {
+	return e.err
+}
+
+// usageErrorf 返回参数错误
+func usageErrorf(format string, args ...interface{}) error {
+	return &cliError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+// withCode 为错误指定退出码，err为nil时返回nil
+func withCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &cliError{code: code, err: err}
+}
+
+// exitCode 按错误类型确定退出码
+func exitCode(err error) int {
+	var (
+		cliErr    *cliError
+		apiErr    *meson.APIError
+		txErr     *helpers.TxFailedError
+		revertErr *helpers.RevertError
+		urlErr    *url.Error
+	)
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &cliErr):
+		return cliErr.code
+	case errors.Is(err, meson.ErrFeeExceeded):
+		return exitFeeExceeded
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.As(err, &apiErr):
+		return exitRelayer
+	case errors.As(err, &txErr), errors.As(err, &revertErr), errors.As(err, &urlErr):
+		// 中继器请求的错误已在上面处理，其余网络错误来自链RPC
+		return exitChain
+	}
+	return exitError
+}
+
+// errorOutput JSON输出模式下的错误
+type errorOutput struct {
+	Error struct {
+		Class   string `json:"class"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// reportError 将错误打印到标准错误，返回退出码
+func reportError(err error) int {
+	code := exitCode(err)
+	if outputFormat == outputText {
+		fmt.Fprintf(stderr, "错误: %v\n", err)
+		return code
+	}
+	out := &errorOutput{}
+	out.Error.Class = errorClasses[code]
+	out.Error.Code = code
+	out.Error.Message = err.Error()
+	enc := json.NewEncoder(stderr)
+	enc.SetEscapeHTML(false)
+	enc.Encode(out)
+	return code
+}
+
+// emit 按输出格式打印结果: json时缩进输出v，ndjson时输出一行，text时调用text
+func (g *globalOptions) emit(v interface{}, text func(w io.Writer)) error {
+	if g.output == outputText {
+		text(stdout)
+		return nil
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetEscapeHTML(false)
+	if g.output == outputJSON {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// confirm 在终端提示确认
+func confirm(prompt string) bool {
+	fmt.Fprintf(stderr, "%s [y/N]: ", prompt)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
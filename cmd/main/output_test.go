@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+func TestExitCode(t *testing.T) {
+	netErr := &url.Error{Op: "Post", URL: "http://rpc", Err: errors.New("connection refused")}
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"nil", nil, exitOK},
+		{"plain", errors.New("失败"), exitError},
+		{"usage", usageErrorf("缺少参数"), exitUsage},
+		{"config", withCode(exitConfig, errors.New("读取配置文件失败")), exitConfig},
+		{"wrapped cli error", fmt.Errorf("外层: %w", withCode(exitSwapFailed, errors.New("已过期"))), exitSwapFailed},
+		{"aborted", errAborted, exitAborted},
+		{"fee exceeded", fmt.Errorf("转账失败: %w", meson.ErrFeeExceeded), exitFeeExceeded},
+		{"timeout", fmt.Errorf("等待失败: %w", context.DeadlineExceeded), exitTimeout},
+		{"relayer status", &meson.APIError{StatusCode: 500, Body: "oops"}, exitRelayer},
+		{"relayer network", fmt.Errorf("询价失败: %w", &meson.APIError{Err: netErr}), exitRelayer},
+		{"rpc network", fmt.Errorf("获取nonce失败: %w", netErr), exitChain},
+		{"tx failed", &helpers.TxFailedError{}, exitChain},
+		{"revert", fmt.Errorf("模拟执行失败: %w", &helpers.RevertError{Reason: "expired"}), exitChain},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.code, exitCode(tt.err))
+		})
+	}
+
+	require.Nil(t, withCode(exitChain, nil))
+	// 每个非0退出码都有JSON输出中的类别
+	for code := exitError; code <= exitAborted; code++ {
+		require.NotEmpty(t, errorClasses[code], code)
+	}
+}
+
+func TestReportError(t *testing.T) {
+	var buf bytes.Buffer
+	saved := stderr
+	stderr = &buf
+	defer func() {
+		stderr = saved
+		outputFormat = outputText
+	}()
+
+	outputFormat = outputText
+	require.Equal(t, exitUsage, reportError(usageErrorf("缺少参数: --amount")))
+	require.Equal(t, "错误: 缺少参数: --amount\n", buf.String())
+
+	for _, format := range []string{outputJSON, outputNDJSON} {
+		buf.Reset()
+		outputFormat = format
+		require.Equal(t, exitFeeExceeded, reportError(fmt.Errorf("手续费<0.1>&过高: %w", meson.ErrFeeExceeded)))
+
+		// 错误对象固定为一行，HTML字符不转义
+		require.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+		require.Contains(t, buf.String(), "<0.1>&")
+		var out struct {
+			Error struct {
+				Class   string `json:"class"`
+				Code    int    `json:"code"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+		require.Equal(t, "fee_exceeded", out.Error.Class)
+		require.Equal(t, exitFeeExceeded, out.Error.Code)
+		require.Contains(t, out.Error.Message, "手续费<0.1>&过高")
+	}
+}
+
+func TestEmit(t *testing.T) {
+	var buf bytes.Buffer
+	saved := stdout
+	stdout = &buf
+	defer func() { stdout = saved }()
+
+	v := map[string]string{"swapId": "0xabc", "note": "a<b>&c"}
+	text := func(w io.Writer) { fmt.Fprintln(w, "跨链交易ID: 0xabc") }
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{outputText, "跨链交易ID: 0xabc\n"},
+		{outputJSON, "{\n  \"note\": \"a<b>&c\",\n  \"swapId\": \"0xabc\"\n}\n"},
+		{outputNDJSON, "{\"note\":\"a<b>&c\",\"swapId\":\"0xabc\"}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			buf.Reset()
+			g := &globalOptions{output: tt.format}
+			require.NoError(t, g.emit(v, text))
+			require.Equal(t, tt.want, buf.String())
+		})
+	}
+}
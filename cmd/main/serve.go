@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mer-coder/meson-bridge/pkg/server"
+)
+
+// shutdownTimeout 停止服务时等待进行中请求的最长时间
+const shutdownTimeout = 30 * time.Second
+
+// runServe 启动REST服务，由签名账户集中发起和管理跨链转账
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	listen := fs.String("listen", "127.0.0.1:8080", "监听地址")
+	apiTokens := fs.String("api-token", os.Getenv("MESON_API_TOKENS"), "允许访问的bearer token，多个用逗号分隔，也可通过MESON_API_TOKENS环境变量或配置文件指定")
+	parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	var tokens []string
+	for _, token := range strings.Split(*apiTokens, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		tokens = g.config.APITokens
+	}
+	if len(tokens) == 0 {
+		return withCode(exitConfig, errors.New("缺少bearer token，请通过--api-token、MESON_API_TOKENS或配置文件指定"))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	bridge, err := g.newBridge()
+	if err != nil {
+		return err
+	}
+	defer g.closeWebhooks()
+	defer bridge.SwapStore().Close()
+	sender, err := g.newSender(ctx, bridge)
+	if err != nil {
+		return err
+	}
+
+	srv := server.New(ctx, bridge, sender, g.chain(), server.Config{Tokens: tokens, ParseToken: parseToken, Log: stderr})
+	if err := srv.Resume(); err != nil {
+		return err
+	}
+	httpServer := &http.Server{
+		Addr:              *listen,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+	fmt.Fprintf(stderr, "服务已启动: http://%s，签名账户: %s，源链: %s\n", *listen, sender.From().Hex(), g.chain())
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("服务异常退出: %w", err)
+	case <-ctx.Done():
+	}
+
+	fmt.Fprintln(stderr, "正在停止服务...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("停止服务失败: %w", err)
+	}
+	srv.Wait()
+	return nil
+}
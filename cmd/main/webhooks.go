@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mer-coder/meson-bridge/pkg/webhook"
+)
+
+// redeliverOutput redeliver子命令的输出
+type redeliverOutput struct {
+	DeadLetters []*webhook.DeadLetter      `json:"deadLetters,omitempty"` // --list时为死信日志内容
+	Results     []*webhook.RedeliverResult `json:"results,omitempty"`
+}
+
+// runRedeliver 重新投递死信日志中的通知，成功的项从日志中移除
+func runRedeliver(args []string) error {
+	fs := flag.NewFlagSet("redeliver", flag.ExitOnError)
+	g := addGlobalFlags(fs)
+	ids := fs.String("id", "", "只重新投递这些事件ID，多个用逗号分隔(默认全部)")
+	list := fs.Bool("list", false, "只列出死信日志，不投递")
+	parseFlags(fs, args)
+	if err := g.validate(); err != nil {
+		return err
+	}
+
+	if *list {
+		letters, err := webhook.ReadDeadLetters(g.config.DeadLetter)
+		if err != nil {
+			return withCode(exitConfig, err)
+		}
+		return g.emit(&redeliverOutput{DeadLetters: letters}, func(w io.Writer) {
+			if len(letters) == 0 {
+				fmt.Fprintln(w, "死信日志为空")
+				return
+			}
+			for _, letter := range letters {
+				fmt.Fprintf(w, "%s %s %s -> %s，已尝试%d次，最后失败: %s (%s)\n", letter.Event.ID, letter.Event.TransferID,
+					letter.Event.Phase, letter.URL, letter.Attempts, letter.LastError, letter.FailedAt.Local().Format(time.DateTime))
+			}
+		})
+	}
+
+	if len(g.config.Webhooks) == 0 {
+		return withCode(exitConfig, errors.New("配置文件中没有webhooks"))
+	}
+	var eventIDs []string
+	for _, id := range strings.Split(*ids, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			eventIDs = append(eventIDs, id)
+		}
+	}
+
+	dispatcher := webhook.NewDispatcher(g.config.Webhooks, g.config.DeadLetter)
+	dispatcher.Log = stderr
+	results, err := dispatcher.Redeliver(context.Background(), eventIDs...)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	if err := g.emit(&redeliverOutput{Results: results}, func(w io.Writer) {
+		for _, result := range results {
+			if result.Error != "" {
+				fmt.Fprintf(w, "%s -> %s: 失败，%s\n", result.EventID, result.URL, result.Error)
+			} else {
+				fmt.Fprintf(w, "%s -> %s: 已投递\n", result.EventID, result.URL)
+			}
+		}
+		fmt.Fprintf(w, "共%d项，成功%d，失败%d\n", len(results), len(results)-failed, failed)
+	}); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d项通知重新投递失败，仍保留在%s中", failed, g.config.DeadLetter)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DefaultConfirmationDepth 未在ConfirmationDepths中配置的链使用的确认深度
+var DefaultConfirmationDepth uint64 = 1
+
+// ConfirmationDepths 按链ID配置交易被视为最终确认所需的区块数(包括交易所在区块)
+var ConfirmationDepths = map[uint64]uint64{
+	1:  12, // Ethereum
+	56: 15, // BNB Chain
+}
+
+// errReorgedOut 交易所在区块被重组，交易已不在链上
+var errReorgedOut = errors.New("交易已被重组移出区块")
+
+// confirmationDepth 返回发送器所在链要求的确认深度
+func (s *Sender) confirmationDepth() uint64 {
+	if s.Confirmations > 0 {
+		return s.Confirmations
+	}
+	if depth, ok := ConfirmationDepths[s.chainID.Uint64()]; ok && depth > 0 {
+		return depth
+	}
+	return DefaultConfirmationDepth
+}
+
+// confirm 等待已被打包的交易达到确认深度
+// 交易被重组移出区块时返回errReorgedOut；被重新打包到其他区块时更新收据并重新计算确认数
+// ctx结束时返回当前确认数下尚未最终确认的结果
+func (s *Sender) confirm(ctx context.Context, tracked *TrackedTx, attempts []*types.Transaction, mined int, receipt *types.Receipt) (*TxResult, error) {
+	depth := s.confirmationDepth()
+	hash := attempts[mined].Hash()
+	reorgs := 0
+
+	for {
+		head, err := s.client.BlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取最新区块失败: %w", err)
+		}
+
+		// 重新查询收据，检查交易是否仍在原区块中
+		current, err := s.client.TransactionReceipt(ctx, hash)
+		if errors.Is(err, ethereum.NotFound) {
+			s.logf("交易 %s 所在区块 %d 被重组，交易已不在链上，继续等待\n", hash.Hex(), receipt.BlockNumber)
+			return nil, errReorgedOut
+		}
+		if err != nil {
+			return nil, fmt.Errorf("查询交易收据失败: %w", err)
+		}
+		if current.BlockHash != receipt.BlockHash {
+			s.logf("交易 %s 被重组，从区块 %d 移至区块 %d\n", hash.Hex(), receipt.BlockNumber, current.BlockNumber)
+			reorgs++
+		}
+		receipt = current
+
+		var confirmations uint64
+		if blockNumber := receipt.BlockNumber.Uint64(); head >= blockNumber {
+			confirmations = head - blockNumber + 1
+		}
+		if confirmations >= depth {
+			result, err := s.result(ctx, tracked, attempts, mined, receipt, confirmations)
+			result.Reorgs = reorgs
+			return result, err
+		}
+
+		s.logf("交易已被打包，确认数 %d/%d，继续等待...\n", confirmations, depth)
+		select {
+		case <-ctx.Done():
+			result, _ := s.result(ctx, tracked, attempts, mined, receipt, confirmations)
+			result.Reorgs = reorgs
+			return result, ctx.Err()
+		case <-time.After(s.PollInterval):
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package helpers
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSender_ConfirmationDepth(t *testing.T) {
+	tests := []struct {
+		name          string
+		chainID       int64
+		confirmations uint64
+		want          uint64
+	}{
+		{"explicit", 1, 3, 3},
+		{"ethereum", 1, 0, 12},
+		{"bnb", 56, 0, 15},
+		{"default", 4200, 0, DefaultConfirmationDepth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Sender{chainID: big.NewInt(tt.chainID), Confirmations: tt.confirmations}
+			require.Equal(t, tt.want, s.confirmationDepth())
+		})
+	}
+}
+
+func TestSender_WaitDetectsReorg(t *testing.T) {
+	blockA, blockB := common.HexToHash("0xaa"), common.HexToHash("0xbb")
+	chain := &fakeChain{gasPrice: big.NewInt(100), head: 9, nonce: 5}
+	chain.onHead = func(head uint64) uint64 { return head + 1 }
+	s := newFakeSender(t, chain)
+	s.Confirmations = 3
+
+	// 第一次查询时交易在区块10，之后被重组到区块11
+	var calls atomic.Int32
+	chain.receipt = func(hash common.Hash) *types.Receipt {
+		if calls.Add(1) == 1 {
+			return newFakeReceipt(hash, 10, blockA)
+		}
+		return newFakeReceipt(hash, 11, blockB)
+	}
+
+	hash := sendTracked(t, s, 5, 1000)
+	result, err := s.Wait(context.Background(), hash)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Reorgs)
+	require.True(t, result.Final)
+	require.EqualValues(t, 3, result.Confirmations)
+	require.Equal(t, blockB, result.Receipt.BlockHash)
+	require.EqualValues(t, 11, result.Receipt.BlockNumber.Uint64())
+}
+
+func TestSender_WaitReorgedOut(t *testing.T) {
+	blockA, blockB := common.HexToHash("0xaa"), common.HexToHash("0xbb")
+	chain := &fakeChain{gasPrice: big.NewInt(100), head: 9, nonce: 5}
+	chain.onHead = func(head uint64) uint64 { return head + 1 }
+	s := newFakeSender(t, chain)
+	s.Confirmations = 2
+
+	// 交易先被打包到区块10，随后被重组移出区块，再被重新打包到区块12
+	var calls atomic.Int32
+	chain.receipt = func(hash common.Hash) *types.Receipt {
+		switch calls.Add(1) {
+		case 1:
+			return newFakeReceipt(hash, 10, blockA)
+		case 2, 3:
+			return nil
+		default:
+			return newFakeReceipt(hash, 12, blockB)
+		}
+	}
+
+	hash := sendTracked(t, s, 5, 1000)
+	result, err := s.Wait(context.Background(), hash)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Reorgs)
+	require.True(t, result.Final)
+	require.Equal(t, blockB, result.Receipt.BlockHash)
+}
+
+func TestSender_ConfirmReorgedOut(t *testing.T) {
+	chain := &fakeChain{gasPrice: big.NewInt(100), head: 10}
+	s := newFakeSender(t, chain)
+	hash := sendTracked(t, s, 0, 1000)
+	tracked, ok := s.Tracked(hash)
+	require.True(t, ok)
+
+	_, err := s.confirm(context.Background(), tracked, tracked.Attempts, 0, newFakeReceipt(hash, 10, common.HexToHash("0xaa")))
+	require.ErrorIs(t, err, errReorgedOut)
+}
@@ -0,0 +1,16 @@
+package helpers
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Logf 将日志写入w，w为nil时写入标准错误
+// 库中的日志都通过调用方注入的io.Writer输出，不写入标准输出，避免与调用方自己的输出混在一起
+func Logf(w io.Writer, format string, args ...interface{}) {
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, format, args...)
+}
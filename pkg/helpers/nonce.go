@@ -0,0 +1,207 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DefaultNonceManager SendTransaction默认使用的nonce管理器(仅内存，不持久化)
+var DefaultNonceManager = newNonceManager("")
+
+// NonceSource 可以查询链上待处理nonce的客户端，*ethclient.Client满足该接口
+type NonceSource interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+}
+
+// NonceManager 按(链, 地址)在本地分配nonce，避免并发交易使用相同的nonce
+type NonceManager struct {
+	mu       sync.Mutex
+	journal  string                 // 持久化文件路径，为空时不持久化
+	accounts map[string]*nonceState // key: chainID:address
+}
+
+// nonceState 单个账户的nonce状态
+type nonceState struct {
+	Next     uint64              `json:"next"`     // 下一个待分配的新nonce
+	Released []uint64            `json:"released"` // 空缺的nonce(广播失败或交易被丢弃)，优先复用
+	reserved map[uint64]struct{} // 已分配但尚未确认广播结果的nonce
+	synced   bool                // 本次运行中是否已与链上同步过
+}
+
+// NewNonceManager 创建nonce管理器，journalPath不为空时从该文件恢复状态，并在每次变更后写回
+func NewNonceManager(journalPath string) (*NonceManager, error) {
+	m := newNonceManager(journalPath)
+	if journalPath == "" {
+		return m, nil
+	}
+
+	data, err := os.ReadFile(journalPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取nonce日志失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &m.accounts); err != nil {
+		return nil, fmt.Errorf("解析nonce日志失败: %w", err)
+	}
+	for _, state := range m.accounts {
+		state.reserved = make(map[uint64]struct{})
+	}
+	return m, nil
+}
+
+func newNonceManager(journalPath string) *NonceManager {
+	return &NonceManager{
+		journal:  journalPath,
+		accounts: make(map[string]*nonceState),
+	}
+}
+
+// Next 分配下一个可用的nonce，优先填补空缺
+// 每个账户在本次运行中首次分配时会与链上同步一次
+func (m *NonceManager) Next(ctx context.Context, client NonceSource, chainID *big.Int, address common.Address) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.state(chainID, address)
+	if !state.synced {
+		if err := m.sync(ctx, client, state, address); err != nil {
+			return 0, err
+		}
+	}
+
+	var nonce uint64
+	if len(state.Released) > 0 {
+		nonce = state.Released[0]
+		state.Released = state.Released[1:]
+	} else {
+		nonce = state.Next
+		state.Next++
+	}
+	state.reserved[nonce] = struct{}{}
+
+	return nonce, m.save()
+}
+
+// Confirm 标记nonce对应的交易已成功广播
+func (m *NonceManager) Confirm(chainID *big.Int, address common.Address, nonce uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.state(chainID, address).reserved, nonce)
+	return m.save()
+}
+
+// Release 归还未能广播的nonce，供后续交易复用
+func (m *NonceManager) Release(chainID *big.Int, address common.Address, nonce uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.state(chainID, address)
+	delete(state.reserved, nonce)
+	state.addReleased(nonce)
+	return m.save()
+}
+
+// Resync 与链上待处理nonce重新同步
+// 链上nonce更大时(如其他程序发送了交易)丢弃过期的本地状态；
+// 链上nonce更小时说明中间有交易被丢弃，这些空缺会在后续分配中优先复用
+func (m *NonceManager) Resync(ctx context.Context, client NonceSource, chainID *big.Int, address common.Address) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.sync(ctx, client, m.state(chainID, address), address); err != nil {
+		return err
+	}
+	return m.save()
+}
+
+// sync 同步链上nonce，调用方需持有锁
+func (m *NonceManager) sync(ctx context.Context, client NonceSource, state *nonceState, address common.Address) error {
+	pending, err := client.PendingNonceAt(ctx, address)
+	if err != nil {
+		return fmt.Errorf("获取nonce失败: %w", err)
+	}
+
+	released := state.Released[:0]
+	for _, nonce := range state.Released {
+		if nonce >= pending {
+			released = append(released, nonce)
+		}
+	}
+	state.Released = released
+	for nonce := range state.reserved {
+		if nonce < pending {
+			delete(state.reserved, nonce)
+		}
+	}
+
+	if pending >= state.Next {
+		state.Next = pending
+	} else {
+		for nonce := pending; nonce < state.Next; nonce++ {
+			if _, ok := state.reserved[nonce]; !ok {
+				state.addReleased(nonce)
+			}
+		}
+	}
+	state.synced = true
+	return nil
+}
+
+// state 获取账户状态，不存在时创建，调用方需持有锁
+func (m *NonceManager) state(chainID *big.Int, address common.Address) *nonceState {
+	key := fmt.Sprintf("%s:%s", chainID, address.Hex())
+	state, ok := m.accounts[key]
+	if !ok {
+		state = &nonceState{reserved: make(map[uint64]struct{})}
+		m.accounts[key] = state
+	}
+	return state
+}
+
+// save 将状态写入日志文件，调用方需持有锁
+func (m *NonceManager) save() error {
+	if m.journal == "" {
+		return nil
+	}
+	data, err := json.Marshal(m.accounts)
+	if err != nil {
+		return fmt.Errorf("序列化nonce日志失败: %w", err)
+	}
+	// 先写临时文件再重命名，避免进程崩溃时日志损坏
+	tmp := m.journal + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("写入nonce日志失败: %w", err)
+	}
+	if err := os.Rename(tmp, m.journal); err != nil {
+		return fmt.Errorf("写入nonce日志失败: %w", err)
+	}
+	return nil
+}
+
+// addReleased 记录空缺nonce，保持升序且不重复
+func (s *nonceState) addReleased(nonce uint64) {
+	i := sort.Search(len(s.Released), func(i int) bool { return s.Released[i] >= nonce })
+	if i < len(s.Released) && s.Released[i] == nonce {
+		return
+	}
+	s.Released = append(s.Released, 0)
+	copy(s.Released[i+1:], s.Released[i:])
+	s.Released[i] = nonce
+}
+
+// isNonceTooLow 判断广播错误是否由nonce过低引起
+func isNonceTooLow(err error) bool {
+	return err != nil && strings.Contains(strings.ToLower(err.Error()), "nonce too low")
+}
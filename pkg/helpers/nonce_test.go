@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeNonceSource struct {
+	pending uint64
+}
+
+func (f *fakeNonceSource) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	return f.pending, nil
+}
+
+func TestNonceManager_Concurrent(t *testing.T) {
+	m, err := NewNonceManager("")
+	require.NoError(t, err)
+	source := &fakeNonceSource{pending: 7}
+	chainID := big.NewInt(4200)
+	addr := common.HexToAddress("0x1")
+
+	var mu sync.Mutex
+	seen := make(map[uint64]bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonce, err := m.Next(context.Background(), source, chainID, addr)
+			require.NoError(t, err)
+			mu.Lock()
+			defer mu.Unlock()
+			require.False(t, seen[nonce], "nonce %d 重复分配", nonce)
+			seen[nonce] = true
+		}()
+	}
+	wg.Wait()
+
+	for nonce := uint64(7); nonce < 27; nonce++ {
+		require.True(t, seen[nonce])
+	}
+}
+
+func TestNonceManager_ReleaseAndGaps(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewNonceManager("")
+	require.NoError(t, err)
+	source := &fakeNonceSource{pending: 0}
+	chainID := big.NewInt(1)
+	addr := common.HexToAddress("0x1")
+
+	for i := 0; i < 4; i++ {
+		nonce, err := m.Next(ctx, source, chainID, addr)
+		require.NoError(t, err)
+		if nonce == 1 {
+			require.NoError(t, m.Release(chainID, addr, nonce))
+		} else {
+			require.NoError(t, m.Confirm(chainID, addr, nonce))
+		}
+	}
+
+	// 广播失败的nonce优先复用
+	nonce, err := m.Next(ctx, source, chainID, addr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), nonce)
+	require.NoError(t, m.Confirm(chainID, addr, nonce))
+
+	// 链上只确认到2，说明2和3被丢弃
+	source.pending = 2
+	require.NoError(t, m.Resync(ctx, source, chainID, addr))
+	nonce, err = m.Next(ctx, source, chainID, addr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), nonce)
+
+	// 其他程序已经用到了10
+	source.pending = 10
+	require.NoError(t, m.Resync(ctx, source, chainID, addr))
+	nonce, err = m.Next(ctx, source, chainID, addr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), nonce)
+}
+
+func TestNonceManager_Journal(t *testing.T) {
+	ctx := context.Background()
+	journal := filepath.Join(t.TempDir(), "nonce.json")
+	source := &fakeNonceSource{pending: 3}
+	chainID := big.NewInt(1)
+	addr := common.HexToAddress("0x1")
+
+	m, err := NewNonceManager(journal)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		nonce, err := m.Next(ctx, source, chainID, addr)
+		require.NoError(t, err)
+		require.NoError(t, m.Confirm(chainID, addr, nonce))
+	}
+
+	// 重启后从日志恢复，链上已包含全部交易
+	source.pending = 6
+	m, err = NewNonceManager(journal)
+	require.NoError(t, err)
+	nonce, err := m.Next(ctx, source, chainID, addr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6), nonce)
+
+	// 重启后从日志恢复，最后一笔交易已被丢弃
+	source.pending = 5
+	m, err = NewNonceManager(journal)
+	require.NoError(t, err)
+	nonce, err = m.Next(ctx, source, chainID, addr)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), nonce)
+}
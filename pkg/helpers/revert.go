@@ -0,0 +1,156 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var (
+	// 标准revert错误的选择器
+	errorSelector = []byte{0x08, 0xc3, 0x79, 0xa0} // Error(string)
+	panicSelector = []byte{0x4e, 0x48, 0x7b, 0x71} // Panic(uint256)
+
+	customErrorsMu sync.RWMutex
+	customErrors   = make(map[[4]byte]abi.Error) // 已注册的自定义错误，按选择器索引
+)
+
+// RegisterErrors 注册合约ABI中的自定义错误，用于解码revert数据
+func RegisterErrors(contractABI abi.ABI) {
+	customErrorsMu.Lock()
+	defer customErrorsMu.Unlock()
+	for _, abiErr := range contractABI.Errors {
+		var selector [4]byte
+		copy(selector[:], abiErr.ID[:4])
+		customErrors[selector] = abiErr
+	}
+}
+
+// RevertError 合约执行revert时返回的错误
+type RevertError struct {
+	Name   string        // 错误名称，如Error、Panic或自定义错误名，无法识别时为空
+	Reason string        // 解码后的revert原因，无法解码时为空
+	Args   []interface{} // 自定义错误的参数
+	Data   []byte        // 原始revert数据
+}
+
+func (e *RevertError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("合约执行回滚: %s", e.Reason)
+	}
+	if len(e.Data) > 0 {
+		return fmt.Sprintf("合约执行回滚, 数据: %s", hexutil.Encode(e.Data))
+	}
+	return "合约执行回滚"
+}
+
+// DecodeRevert 解码revert数据，支持Error(string)、Panic(uint256)和通过RegisterErrors注册的自定义错误
+func DecodeRevert(data []byte) *RevertError {
+	revertErr := &RevertError{Data: data}
+	if len(data) < 4 {
+		return revertErr
+	}
+
+	switch {
+	case bytes.Equal(data[:4], errorSelector):
+		revertErr.Name = "Error"
+	case bytes.Equal(data[:4], panicSelector):
+		revertErr.Name = "Panic"
+	default:
+		var selector [4]byte
+		copy(selector[:], data[:4])
+		customErrorsMu.RLock()
+		abiErr, ok := customErrors[selector]
+		customErrorsMu.RUnlock()
+		if !ok {
+			return revertErr
+		}
+		args, err := abiErr.Inputs.Unpack(data[4:])
+		if err != nil {
+			return revertErr
+		}
+		revertErr.Name = abiErr.Name
+		revertErr.Args = args
+		revertErr.Reason = formatCustomError(abiErr, args)
+		return revertErr
+	}
+
+	if reason, err := abi.UnpackRevert(data); err == nil {
+		revertErr.Reason = reason
+	}
+	return revertErr
+}
+
+// formatCustomError 将自定义错误格式化为 Name(arg1, arg2) 的形式
+func formatCustomError(abiErr abi.Error, args []interface{}) string {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = fmt.Sprintf("%v", arg)
+		if addr, ok := arg.(common.Address); ok {
+			parts[i] = addr.Hex()
+		}
+	}
+	return fmt.Sprintf("%s(%s)", abiErr.Name, strings.Join(parts, ", "))
+}
+
+// TxFailedError 交易已上链但执行失败
+type TxFailedError struct {
+	TxHash      common.Hash
+	BlockNumber *big.Int
+	BlockHash   common.Hash
+	GasUsed     uint64
+	Revert      *RevertError // 重放得到的revert原因，无法复现时为nil
+}
+
+func (e *TxFailedError) Error() string {
+	msg := fmt.Sprintf("交易执行失败, 哈希: %s, 区块: %s", e.TxHash.Hex(), e.BlockNumber)
+	if e.Revert != nil {
+		return fmt.Sprintf("%s, %s", msg, e.Revert.Error())
+	}
+	return msg
+}
+
+func (e *TxFailedError) Unwrap() error {
+	if e.Revert == nil {
+		return nil
+	}
+	return e.Revert
+}
+
+// txFailure 在交易所在区块通过eth_call重放失败的交易，解码revert原因
+func (s *Sender) txFailure(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) *TxFailedError {
+	failure := &TxFailedError{
+		TxHash:      tx.Hash(),
+		BlockNumber: receipt.BlockNumber,
+		BlockHash:   receipt.BlockHash,
+		GasUsed:     receipt.GasUsed,
+	}
+
+	msg := ethereum.CallMsg{
+		From:     s.from,
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}
+	if _, err := s.client.CallContract(ctx, msg, receipt.BlockNumber); err != nil {
+		if revertErr, ok := wrapCallError(err).(*RevertError); ok {
+			failure.Revert = revertErr
+		}
+	}
+	// 没有revert数据且gas已用完，说明是gas耗尽
+	if failure.Revert == nil && receipt.GasUsed >= tx.Gas() {
+		failure.Revert = &RevertError{Reason: "gas耗尽"}
+	}
+	return failure
+}
@@ -0,0 +1,431 @@
+package helpers
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+const (
+	// minBumpPercent 节点接受替换交易要求的最低gas价格涨幅
+	minBumpPercent = 10
+	// cancelGasLimit 取消交易(向自己转账0)的gas上限
+	cancelGasLimit = 21000
+)
+
+// Sender 交易发送器，跟踪已发送的交易，支持卡住交易的加速和取消
+type Sender struct {
+	client     *ethclient.Client
+	chainID    *big.Int
+	privateKey *ecdsa.PrivateKey
+	from       common.Address
+
+	Nonces        *NonceManager // nonce管理器
+	StuckTimeout  time.Duration // 交易超过该时间未被打包时自动加速，为0时不自动加速
+	BumpPercent   int64         // 每次替换提高gas价格的百分比，低于10时按10处理
+	MaxBumps      int           // 自动加速的最大次数
+	PollInterval  time.Duration // 查询收据的间隔
+	Confirmations uint64        // 要求的确认深度，为0时使用ConfirmationDepths中该链的配置
+	Log           io.Writer     // 日志输出，默认为标准错误
+
+	mu  sync.Mutex
+	txs map[common.Hash]*TrackedTx // 按交易哈希索引，替换交易与原交易指向同一条记录
+}
+
+// TrackedTx 同一nonce下的一组交易，包括原交易及其所有替换交易
+type TrackedTx struct {
+	Nonce     uint64
+	Attempts  []*types.Transaction // 按发送顺序排列，第一个为原交易
+	Cancelled bool                 // 是否已发送取消交易
+	sentAt    time.Time            // 最近一次广播时间
+}
+
+// TxResult 交易最终结果
+type TxResult struct {
+	Hash      common.Hash    // 实际被打包的交易哈希
+	Receipt   *types.Receipt // 实际被打包的交易收据
+	Attempts  []common.Hash  // 同一nonce下发送过的所有交易哈希
+	Replaced  bool           // 被打包的是否为替换交易
+	Cancelled bool           // 被打包的是否为取消交易
+
+	Confirmations uint64 // 返回时的确认数(包括交易所在区块)
+	Final         bool   // 确认数是否已达到要求的确认深度
+	Reorgs        int    // 等待期间检测到的重组次数
+}
+
+// NewSender 创建交易发送器
+func NewSender(client *ethclient.Client, chainID *big.Int, privateKey *ecdsa.PrivateKey) *Sender {
+	return &Sender{
+		client:       client,
+		chainID:      chainID,
+		privateKey:   privateKey,
+		from:         crypto.PubkeyToAddress(privateKey.PublicKey),
+		Nonces:       DefaultNonceManager,
+		StuckTimeout: 3 * time.Minute,
+		BumpPercent:  12,
+		MaxBumps:     5,
+		PollInterval: 2 * time.Second,
+		Log:          os.Stderr,
+		txs:          make(map[common.Hash]*TrackedTx),
+	}
+}
+
+// From 返回发送地址
+func (s *Sender) From() common.Address {
+	return s.from
+}
+
+// SignHash 对32字节哈希直接签名，v值调整为27/28(与ethers.js保持一致)
+// 用于签名Meson中继器返回的SigningRequest.Hash
+func (s *Sender) SignHash(hash []byte) ([]byte, error) {
+	return SignHash(hash, s.privateKey)
+}
+
+// Send 模拟执行、广播交易并等待确认
+// 交易广播后即使等待失败也会返回已发送的交易哈希
+func (s *Sender) Send(ctx context.Context, txData *TxData) (*TxResult, error) {
+	hash, err := s.Broadcast(ctx, txData)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.Wait(ctx, hash)
+	if result == nil {
+		return &TxResult{Hash: hash, Attempts: []common.Hash{hash}}, err
+	}
+	return result, err
+}
+
+// Broadcast 模拟执行并广播交易，不等待确认
+func (s *Sender) Broadcast(ctx context.Context, txData *TxData) (common.Hash, error) {
+	// 设置交易值，如果未指定则默认为0
+	value := big.NewInt(0)
+	if txData.Value != nil {
+		value = txData.Value
+	}
+
+	s.logf("钱包地址: %s\n", s.from.Hex())
+
+	// 获取GasPrice
+	gasPrice, err := s.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("获取gas价格失败: %w", err)
+	}
+	s.logf("使用的Gas价格: %s\n", gasPrice.String())
+
+	// 广播前先模拟执行，合约会revert的交易不上链，避免浪费gas
+	if err := SimulateTransaction(ctx, s.client, s.from, txData); err != nil {
+		return common.Hash{}, fmt.Errorf("交易模拟执行失败: %w", err)
+	}
+
+	// 估算gas上限
+	gasLimit := txData.Gas
+	if gasLimit == 0 {
+		gasLimit, err = EstimateGas(ctx, s.client, s.from, txData)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("估算gas失败: %w", err)
+		}
+	}
+	s.logf("使用的Gas上限: %d\n", gasLimit)
+
+	// 从nonce管理器分配nonce并广播，本地nonce落后于链上时重新同步后重试一次
+	for attempt := 0; ; attempt++ {
+		nonce, err := s.Nonces.Next(ctx, s.client, s.chainID, s.from)
+		if err != nil {
+			return common.Hash{}, err
+		}
+
+		// 创建交易对象
+		tx := types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: gasPrice,
+			Gas:      gasLimit,
+			To:       &txData.To,
+			Value:    value,
+			Data:     txData.Data,
+		})
+		s.logf("即将发送交易，使用Nonce: %d\n", nonce)
+		s.logf("交易目标地址: %s\n", txData.To.Hex())
+
+		signedTx, err := s.signAndSend(ctx, tx)
+		if err == nil {
+			if err := s.Nonces.Confirm(s.chainID, s.from, nonce); err != nil {
+				s.logf("记录nonce失败: %v\n", err)
+			}
+			s.track(&TrackedTx{Nonce: nonce}, signedTx)
+			s.logf("交易已发送! 哈希: %s\n", signedTx.Hash().Hex())
+			return signedTx.Hash(), nil
+		}
+		if isNonceTooLow(err) && attempt == 0 {
+			s.logf("Nonce %d 已被使用，重新同步后重试\n", nonce)
+			if err := s.Nonces.Resync(ctx, s.client, s.chainID, s.from); err != nil {
+				return common.Hash{}, err
+			}
+			continue
+		}
+		if err := s.Nonces.Release(s.chainID, s.from, nonce); err != nil {
+			s.logf("释放nonce失败: %v\n", err)
+		}
+		s.logf("发送交易失败: %v\n", err)
+		return common.Hash{}, err
+	}
+}
+
+// SpeedUp 以相同nonce、更高的gas价格重新发送交易，返回替换交易的哈希
+func (s *Sender) SpeedUp(ctx context.Context, txHash common.Hash) (common.Hash, error) {
+	tracked, last, err := s.latest(txHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	gasPrice, err := s.bumpedGasPrice(ctx, last.GasPrice())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    tracked.Nonce,
+		GasPrice: gasPrice,
+		Gas:      last.Gas(),
+		To:       last.To(),
+		Value:    last.Value(),
+		Data:     last.Data(),
+	})
+	return s.replace(ctx, tracked, tx)
+}
+
+// CancelTx 以相同nonce向自己发送0金额交易来取消卡住的交易，返回取消交易的哈希
+func (s *Sender) CancelTx(ctx context.Context, txHash common.Hash) (common.Hash, error) {
+	tracked, last, err := s.latest(txHash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	gasPrice, err := s.bumpedGasPrice(ctx, last.GasPrice())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	to := s.from
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    tracked.Nonce,
+		GasPrice: gasPrice,
+		Gas:      cancelGasLimit,
+		To:       &to,
+		Value:    big.NewInt(0),
+	})
+	hash, err := s.replace(ctx, tracked, tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	s.mu.Lock()
+	tracked.Cancelled = true
+	s.mu.Unlock()
+	return hash, nil
+}
+
+// Wait 等待交易(或其任一替换交易)被打包并达到确认深度，超过StuckTimeout未被打包时自动加速
+func (s *Sender) Wait(ctx context.Context, txHash common.Hash) (*TxResult, error) {
+	s.mu.Lock()
+	tracked, ok := s.txs[txHash]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("未知的交易: %s", txHash.Hex())
+	}
+
+	s.logf("正在等待交易确认...\n")
+	bumps, reorgs := 0, 0
+	for {
+		s.mu.Lock()
+		attempts := append([]*types.Transaction(nil), tracked.Attempts...)
+		sentAt := tracked.sentAt
+		s.mu.Unlock()
+
+		// 任一交易被打包后等待足够的确认数，期间被重组移出区块则继续等待
+		mined, receipt, err := s.findReceipt(ctx, attempts)
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			result, err := s.confirm(ctx, tracked, attempts, mined, receipt)
+			if errors.Is(err, errReorgedOut) {
+				reorgs++
+				continue
+			}
+			if result != nil {
+				result.Reorgs += reorgs
+			}
+			return result, err
+		}
+
+		// 所有交易都没有收据，但nonce已被使用，再确认一次收据后判定为被其他交易替换
+		confirmed, err := s.client.NonceAt(ctx, s.from, nil)
+		if err != nil {
+			return nil, fmt.Errorf("获取nonce失败: %w", err)
+		}
+		if confirmed > tracked.Nonce {
+			_, receipt, err := s.findReceipt(ctx, attempts)
+			if err != nil {
+				return nil, err
+			}
+			if receipt != nil {
+				continue
+			}
+			return nil, fmt.Errorf("nonce %d 已被其他交易使用，交易 %s 未被打包", tracked.Nonce, txHash.Hex())
+		}
+
+		if s.StuckTimeout > 0 && bumps < s.MaxBumps && time.Since(sentAt) >= s.StuckTimeout {
+			bumps++
+			last := attempts[len(attempts)-1]
+			s.logf("交易超过%s未被打包，第%d次加速\n", s.StuckTimeout, bumps)
+			if _, err := s.SpeedUp(ctx, last.Hash()); err != nil {
+				s.logf("加速交易失败: %v\n", err)
+				// 推迟下一次加速，避免连续失败
+				s.mu.Lock()
+				tracked.sentAt = time.Now()
+				s.mu.Unlock()
+			}
+			continue
+		}
+
+		s.logf("交易仍在等待确认，继续等待...\n")
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(s.PollInterval):
+		}
+	}
+}
+
+// Tracked 返回交易所在的跟踪记录
+func (s *Sender) Tracked(txHash common.Hash) (*TrackedTx, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tracked, ok := s.txs[txHash]
+	return tracked, ok
+}
+
+// findReceipt 查询一组交易中被打包的那个，从最新的替换交易开始查询，都未被打包时返回nil
+func (s *Sender) findReceipt(ctx context.Context, attempts []*types.Transaction) (int, *types.Receipt, error) {
+	for i := len(attempts) - 1; i >= 0; i-- {
+		receipt, err := s.client.TransactionReceipt(ctx, attempts[i].Hash())
+		if err == nil {
+			return i, receipt, nil
+		}
+		// 交易尚未被打包时继续查询下一个
+		if !errors.Is(err, ethereum.NotFound) {
+			return 0, nil, err
+		}
+	}
+	return 0, nil, nil
+}
+
+// result 根据被打包的交易生成结果
+func (s *Sender) result(ctx context.Context, tracked *TrackedTx, attempts []*types.Transaction, mined int, receipt *types.Receipt, confirmations uint64) (*TxResult, error) {
+	result := &TxResult{
+		Hash:          attempts[mined].Hash(),
+		Receipt:       receipt,
+		Replaced:      mined > 0,
+		Confirmations: confirmations,
+		Final:         confirmations >= s.confirmationDepth(),
+	}
+	for _, tx := range attempts {
+		result.Attempts = append(result.Attempts, tx.Hash())
+	}
+	// 取消交易是发给自己的空交易
+	s.mu.Lock()
+	result.Cancelled = tracked.Cancelled && *attempts[mined].To() == s.from && len(attempts[mined].Data()) == 0
+	s.mu.Unlock()
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		failure := s.txFailure(ctx, attempts[mined], receipt)
+		s.logf("交易已确认但执行失败! 区块高度: %d, 原因: %v\n", receipt.BlockNumber, failure.Revert)
+		return result, failure
+	}
+	if !result.Final {
+		return result, nil
+	}
+	s.logf("交易已确认成功! 哈希: %s, 区块高度: %d, 确认数: %d, Gas使用: %d\n", result.Hash.Hex(), receipt.BlockNumber, confirmations, receipt.GasUsed)
+	return result, nil
+}
+
+// latest 返回交易所在的跟踪记录及其最新一次发送的交易
+func (s *Sender) latest(txHash common.Hash) (*TrackedTx, *types.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tracked, ok := s.txs[txHash]
+	if !ok {
+		return nil, nil, fmt.Errorf("未知的交易: %s", txHash.Hex())
+	}
+	return tracked, tracked.Attempts[len(tracked.Attempts)-1], nil
+}
+
+// bumpedGasPrice 计算替换交易的gas价格，取按比例上浮后的价格与当前建议价格中的较大者
+func (s *Sender) bumpedGasPrice(ctx context.Context, old *big.Int) (*big.Int, error) {
+	percent := s.BumpPercent
+	if percent < minBumpPercent {
+		percent = minBumpPercent
+	}
+	bumped := new(big.Int).Mul(old, big.NewInt(100+percent))
+	bumped.Div(bumped, big.NewInt(100))
+	// 整数除法可能向下取整，确保至少上涨1wei
+	if bumped.Cmp(old) <= 0 {
+		bumped.Add(old, big.NewInt(1))
+	}
+
+	suggested, err := s.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取gas价格失败: %w", err)
+	}
+	if suggested.Cmp(bumped) > 0 {
+		return suggested, nil
+	}
+	return bumped, nil
+}
+
+// replace 发送替换交易并记录到同一跟踪记录
+func (s *Sender) replace(ctx context.Context, tracked *TrackedTx, tx *types.Transaction) (common.Hash, error) {
+	signedTx, err := s.signAndSend(ctx, tx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("发送替换交易失败: %w", err)
+	}
+	s.track(tracked, signedTx)
+	s.logf("替换交易已发送! 哈希: %s, Gas价格: %s\n", signedTx.Hash().Hex(), tx.GasPrice())
+	return signedTx.Hash(), nil
+}
+
+// signAndSend 签名并广播交易
+func (s *Sender) signAndSend(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(s.chainID), s.privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("签名交易失败: %w", err)
+	}
+	if err := s.client.SendTransaction(ctx, signedTx); err != nil {
+		return nil, fmt.Errorf("发送交易失败: %w", err)
+	}
+	return signedTx, nil
+}
+
+// track 记录已广播的交易
+func (s *Sender) track(tracked *TrackedTx, tx *types.Transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tracked.Attempts = append(tracked.Attempts, tx)
+	tracked.sentAt = time.Now()
+	s.txs[tx.Hash()] = tracked
+}
+
+// logf 输出日志
+func (s *Sender) logf(format string, args ...interface{}) {
+	Logf(s.Log, format, args...)
+}
@@ -0,0 +1,219 @@
+package helpers
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChain 进程内的模拟节点，实现Sender用到的eth_*方法
+type fakeChain struct {
+	mu       sync.Mutex
+	gasPrice *big.Int
+	head     uint64
+	nonce    uint64                                // 已确认的nonce
+	sent     []*types.Transaction                  // 按广播顺序
+	receipt  func(hash common.Hash) *types.Receipt // 返回nil表示尚未打包
+	onHead   func(head uint64) uint64              // 每次查询最新区块时调用，可推进区块高度
+}
+
+func (f *fakeChain) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(4200))
+}
+
+func (f *fakeChain) GasPrice() *hexutil.Big {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return (*hexutil.Big)(f.gasPrice)
+}
+
+func (f *fakeChain) BlockNumber() hexutil.Uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.onHead != nil {
+		f.head = f.onHead(f.head)
+	}
+	return hexutil.Uint64(f.head)
+}
+
+func (f *fakeChain) GetTransactionCount(addr common.Address, block string) hexutil.Uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return hexutil.Uint64(f.nonce)
+}
+
+func (f *fakeChain) SendRawTransaction(raw hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, tx)
+	return tx.Hash(), nil
+}
+
+func (f *fakeChain) GetTransactionReceipt(hash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	receipt := f.receipt
+	f.mu.Unlock()
+	if receipt == nil {
+		return nil, nil
+	}
+	return receipt(hash), nil
+}
+
+// sentTxs 返回已广播的交易
+func (f *fakeChain) sentTxs() []*types.Transaction {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*types.Transaction(nil), f.sent...)
+}
+
+// newFakeReceipt 生成成功的收据
+func newFakeReceipt(hash common.Hash, block uint64, blockHash common.Hash) *types.Receipt {
+	return &types.Receipt{
+		Status:      types.ReceiptStatusSuccessful,
+		TxHash:      hash,
+		BlockNumber: new(big.Int).SetUint64(block),
+		BlockHash:   blockHash,
+		GasUsed:     21000,
+		Logs:        []*types.Log{},
+	}
+}
+
+// newFakeSender 创建连接模拟节点的Sender
+func newFakeSender(t *testing.T, chain *fakeChain) *Sender {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", chain))
+	client := rpc.DialInProc(server)
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	s := NewSender(ethclient.NewClient(client), big.NewInt(4200), key)
+	s.PollInterval = time.Millisecond
+	s.Confirmations = 1
+	return s
+}
+
+// sendTracked 直接签名广播一笔交易并开始跟踪，跳过模拟执行和gas估算
+func sendTracked(t *testing.T, s *Sender, nonce uint64, gasPrice int64) common.Hash {
+	to := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	signed, err := s.signAndSend(context.Background(), types.NewTx(&types.LegacyTx{
+		Nonce:    nonce,
+		GasPrice: big.NewInt(gasPrice),
+		Gas:      60000,
+		To:       &to,
+		Value:    big.NewInt(0),
+		Data:     []byte{0x01},
+	}))
+	require.NoError(t, err)
+	s.track(&TrackedTx{Nonce: nonce}, signed)
+	return signed.Hash()
+}
+
+func TestSender_BumpedGasPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		percent   int64
+		old       int64
+		suggested int64
+		want      int64
+	}{
+		{"percent", 12, 1000, 1, 1120},
+		{"minimum 10 percent", 5, 1000, 1, 1100},
+		{"rounds up at least 1 wei", 12, 1, 1, 2},
+		{"suggested is higher", 12, 1000, 2000, 2000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeSender(t, &fakeChain{gasPrice: big.NewInt(tt.suggested)})
+			s.BumpPercent = tt.percent
+			got, err := s.bumpedGasPrice(context.Background(), big.NewInt(tt.old))
+			require.NoError(t, err)
+			require.Equal(t, big.NewInt(tt.want), got)
+		})
+	}
+}
+
+func TestSender_SpeedUpAndCancel(t *testing.T) {
+	chain := &fakeChain{gasPrice: big.NewInt(100)}
+	s := newFakeSender(t, chain)
+	ctx := context.Background()
+
+	original := sendTracked(t, s, 5, 1000)
+	spedUp, err := s.SpeedUp(ctx, original)
+	require.NoError(t, err)
+
+	sent := chain.sentTxs()
+	require.Len(t, sent, 2)
+	replacement := sent[1]
+	require.Equal(t, spedUp, replacement.Hash())
+	require.EqualValues(t, 5, replacement.Nonce())
+	require.Equal(t, big.NewInt(1120), replacement.GasPrice())
+	require.Equal(t, sent[0].Data(), replacement.Data())
+	require.Equal(t, sent[0].To(), replacement.To())
+
+	// 取消基于最新一次发送的交易继续加价，向自己发送0金额
+	cancelled, err := s.CancelTx(ctx, original)
+	require.NoError(t, err)
+	sent = chain.sentTxs()
+	require.Len(t, sent, 3)
+	cancelTx := sent[2]
+	require.Equal(t, cancelled, cancelTx.Hash())
+	require.EqualValues(t, 5, cancelTx.Nonce())
+	require.Equal(t, big.NewInt(1254), cancelTx.GasPrice())
+	require.Equal(t, s.From(), *cancelTx.To())
+	require.EqualValues(t, cancelGasLimit, cancelTx.Gas())
+	require.Empty(t, cancelTx.Data())
+
+	tracked, ok := s.Tracked(cancelled)
+	require.True(t, ok)
+	require.True(t, tracked.Cancelled)
+	require.Len(t, tracked.Attempts, 3)
+
+	_, err = s.SpeedUp(ctx, common.HexToHash("0x1234"))
+	require.ErrorContains(t, err, "未知的交易")
+}
+
+func TestSender_WaitAutoBump(t *testing.T) {
+	chain := &fakeChain{gasPrice: big.NewInt(100), head: 10, nonce: 5}
+	s := newFakeSender(t, chain)
+	s.StuckTimeout = time.Millisecond
+	s.MaxBumps = 1
+
+	// 只有加速后的替换交易被打包
+	blockHash := common.HexToHash("0xaa")
+	chain.receipt = func(hash common.Hash) *types.Receipt {
+		sent := chain.sentTxs()
+		if len(sent) == 2 && hash == sent[1].Hash() {
+			return newFakeReceipt(hash, 10, blockHash)
+		}
+		return nil
+	}
+
+	original := sendTracked(t, s, 5, 1000)
+	time.Sleep(2 * time.Millisecond)
+	result, err := s.Wait(context.Background(), original)
+	require.NoError(t, err)
+	require.True(t, result.Replaced)
+	require.False(t, result.Cancelled)
+	require.True(t, result.Final)
+	require.Len(t, result.Attempts, 2)
+	require.Equal(t, chain.sentTxs()[1].Hash(), result.Hash)
+	require.Equal(t, big.NewInt(1120), chain.sentTxs()[1].GasPrice())
+}
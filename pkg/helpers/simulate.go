@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// GasLimitMargin 估算gas之后额外增加的安全余量(百分比)
+var GasLimitMargin uint64 = 20
+
+// SimulateTransaction 使用eth_call模拟执行交易，合约revert时返回*RevertError
+func SimulateTransaction(ctx context.Context, client *ethclient.Client, from common.Address, txData *TxData) error {
+	_, err := client.CallContract(ctx, callMsg(from, txData), nil)
+	if err != nil {
+		return wrapCallError(err)
+	}
+	return nil
+}
+
+// EstimateGas 估算交易的gas上限，并加上GasLimitMargin的安全余量
+func EstimateGas(ctx context.Context, client *ethclient.Client, from common.Address, txData *TxData) (uint64, error) {
+	gas, err := client.EstimateGas(ctx, callMsg(from, txData))
+	if err != nil {
+		return 0, wrapCallError(err)
+	}
+	return gas + gas*GasLimitMargin/100, nil
+}
+
+// callMsg 将交易数据转换为eth_call请求
+func callMsg(from common.Address, txData *TxData) ethereum.CallMsg {
+	to := txData.To
+	return ethereum.CallMsg{
+		From:  from,
+		To:    &to,
+		Value: txData.Value,
+		Data:  txData.Data,
+	}
+}
+
+// wrapCallError 从RPC错误中提取revert数据，转换为*RevertError
+func wrapCallError(err error) error {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if hexData, ok := dataErr.ErrorData().(string); ok {
+			if data, decodeErr := hexutil.Decode(hexData); decodeErr == nil {
+				return DecodeRevert(data)
+			}
+		}
+	}
+	// 部分节点只在错误信息中返回revert原因，不带revert数据
+	if msg := err.Error(); strings.HasPrefix(msg, "execution reverted") {
+		return &RevertError{Reason: strings.TrimPrefix(strings.TrimPrefix(msg, "execution reverted"), ": ")}
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+type testDataError struct {
+	data string
+}
+
+func (e *testDataError) Error() string          { return "execution reverted" }
+func (e *testDataError) ErrorData() interface{} { return e.data }
+
+func packError(t *testing.T, sig, typ string, value interface{}) []byte {
+	abiType, err := abi.NewType(typ, "", nil)
+	require.NoError(t, err)
+	packed, err := abi.Arguments{{Type: abiType}}.Pack(value)
+	require.NoError(t, err)
+	return append(crypto.Keccak256([]byte(sig))[:4], packed...)
+}
+
+func TestDecodeRevert(t *testing.T) {
+	revertErr := DecodeRevert(packError(t, "Error(string)", "string", "ERC20: insufficient allowance"))
+	require.Equal(t, "Error", revertErr.Name)
+	require.Equal(t, "ERC20: insufficient allowance", revertErr.Reason)
+
+	revertErr = DecodeRevert(packError(t, "Panic(uint256)", "uint256", big.NewInt(0x11)))
+	require.Contains(t, revertErr.Reason, "overflow")
+
+	revertErr = DecodeRevert([]byte{0xde, 0xad, 0xbe, 0xef})
+	require.Empty(t, revertErr.Reason)
+	require.Contains(t, revertErr.Error(), "0xdeadbeef")
+}
+
+func TestDecodeRevert_CustomError(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(`[{"type":"error","name":"ERC20InsufficientAllowance","inputs":[{"name":"spender","type":"address"},{"name":"allowance","type":"uint256"},{"name":"needed","type":"uint256"}]}]`))
+	require.NoError(t, err)
+	RegisterErrors(parsed)
+
+	spender := common.HexToAddress("0x25aB3Efd52e6470681CE037cD546Dc60726948D3")
+	abiErr := parsed.Errors["ERC20InsufficientAllowance"]
+	data, err := abiErr.Inputs.Pack(spender, big.NewInt(0), big.NewInt(100))
+	require.NoError(t, err)
+	data = append(abiErr.ID.Bytes()[:4], data...)
+
+	revertErr := DecodeRevert(data)
+	require.Equal(t, "ERC20InsufficientAllowance", revertErr.Name)
+	require.Equal(t, "ERC20InsufficientAllowance("+spender.Hex()+", 0, 100)", revertErr.Reason)
+	require.Len(t, revertErr.Args, 3)
+}
+
+func TestWrapCallError(t *testing.T) {
+	data := packError(t, "Error(string)", "string", "Swap already exists")
+	err := wrapCallError(&testDataError{data: hexutil.Encode(data)})
+
+	var revertErr *RevertError
+	require.True(t, errors.As(err, &revertErr))
+	require.Equal(t, "Swap already exists", revertErr.Reason)
+
+	err = wrapCallError(errors.New("execution reverted: paused"))
+	require.True(t, errors.As(err, &revertErr))
+	require.Equal(t, "paused", revertErr.Reason)
+
+	plain := errors.New("connection refused")
+	require.Equal(t, plain, wrapCallError(plain))
+}
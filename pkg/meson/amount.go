@@ -0,0 +1,131 @@
+package meson
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/shopspring/decimal"
+)
+
+// MesonDecimals Meson合约内部统一使用的金额精度，跨链金额最多6位小数
+const MesonDecimals = 6
+
+// TokenAmount 带精度的代币金额，以人类可读单位保存，转换为最小单位时不经过浮点数
+type TokenAmount struct {
+	value    decimal.Decimal
+	decimals int32
+}
+
+// NewTokenAmount 创建代币金额，小数位数超过代币精度或金额为负时返回错误
+func NewTokenAmount(value decimal.Decimal, decimals int32) (TokenAmount, error) {
+	if decimals < 0 {
+		return TokenAmount{}, fmt.Errorf("无效的代币精度: %d", decimals)
+	}
+	if value.IsNegative() {
+		return TokenAmount{}, fmt.Errorf("金额不能为负: %s", value)
+	}
+	if !value.Equal(value.Truncate(decimals)) {
+		return TokenAmount{}, fmt.Errorf("金额%s超过代币精度%d位小数", value, decimals)
+	}
+	return TokenAmount{value: value, decimals: decimals}, nil
+}
+
+// ParseTokenAmount 解析人类可读的金额字符串，如"0.01"
+func ParseTokenAmount(s string, decimals int32) (TokenAmount, error) {
+	value, err := decimal.NewFromString(s)
+	if err != nil {
+		return TokenAmount{}, fmt.Errorf("无效的金额: %s", s)
+	}
+	return NewTokenAmount(value, decimals)
+}
+
+// ParseMesonAmount 按Meson的6位精度解析跨链金额
+func ParseMesonAmount(s string) (TokenAmount, error) {
+	return ParseTokenAmount(s, MesonDecimals)
+}
+
+// TokenAmountFromBase 由最小单位创建代币金额，如链上查询到的余额
+func TokenAmountFromBase(base *big.Int, decimals int32) TokenAmount {
+	return TokenAmount{value: decimal.NewFromBigInt(base, -decimals), decimals: decimals}
+}
+
+// BaseUnits 返回最小单位表示的金额
+func (a TokenAmount) BaseUnits() *big.Int {
+	return a.value.Shift(a.decimals).BigInt()
+}
+
+// Decimal 返回人类可读单位的金额
+func (a TokenAmount) Decimal() decimal.Decimal {
+	return a.value
+}
+
+// Decimals 返回代币精度
+func (a TokenAmount) Decimals() int32 {
+	return a.decimals
+}
+
+// ToDecimals 转换为另一种精度，会丢失精度时返回错误
+func (a TokenAmount) ToDecimals(decimals int32) (TokenAmount, error) {
+	return NewTokenAmount(a.value, decimals)
+}
+
+// MesonAmount 转换为Meson使用的6位精度
+func (a TokenAmount) MesonAmount() (TokenAmount, error) {
+	return a.ToDecimals(MesonDecimals)
+}
+
+// IsZero 金额是否为0
+func (a TokenAmount) IsZero() bool {
+	return a.value.IsZero()
+}
+
+// Cmp 比较两个金额，与精度无关
+func (a TokenAmount) Cmp(other TokenAmount) int {
+	return a.value.Cmp(other.value)
+}
+
+// String 返回人类可读单位的字符串，如"0.01"
+func (a TokenAmount) String() string {
+	return a.value.String()
+}
+
+// tokenAmountJSON TokenAmount的JSON形式，同时保存精度以便还原最小单位
+type tokenAmountJSON struct {
+	Value    string `json:"value"`    // 人类可读单位的金额
+	Decimals int32  `json:"decimals"` // 代币精度
+}
+
+// MarshalJSON 序列化为{"value","decimals"}，反序列化后BaseUnits不变
+func (a TokenAmount) MarshalJSON() ([]byte, error) {
+	return json.Marshal(tokenAmountJSON{Value: a.value.String(), Decimals: a.decimals})
+}
+
+// UnmarshalJSON 从{"value","decimals"}反序列化
+// 兼容旧格式的字符串，此时精度取MesonDecimals和实际小数位数中的较大者
+func (a *TokenAmount) UnmarshalJSON(data []byte) error {
+	var v tokenAmountJSON
+	legacy := json.Unmarshal(data, &v.Value) == nil
+	if !legacy {
+		if err := json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("无效的金额: %s", data)
+		}
+	}
+	value, err := decimal.NewFromString(v.Value)
+	if err != nil {
+		return fmt.Errorf("无效的金额: %s", v.Value)
+	}
+	decimals := v.Decimals
+	if legacy {
+		decimals = MesonDecimals
+		if exp := -value.Exponent(); exp > decimals {
+			decimals = exp
+		}
+	}
+	amount, err := NewTokenAmount(value, decimals)
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
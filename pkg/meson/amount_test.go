@@ -0,0 +1,72 @@
+package meson
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenAmount(t *testing.T) {
+	amount, err := ParseTokenAmount("0.01", 18)
+	require.NoError(t, err)
+	expected, _ := new(big.Int).SetString("10000000000000000", 10)
+	require.Equal(t, expected, amount.BaseUnits())
+	require.Equal(t, "0.01", amount.String())
+
+	meson, err := amount.MesonAmount()
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(10000), meson.BaseUnits())
+
+	// 18位精度的代币允许更多小数，但超过Meson的6位精度
+	amount, err = ParseTokenAmount("1.0000001", 18)
+	require.NoError(t, err)
+	_, err = amount.MesonAmount()
+	require.Error(t, err)
+
+	_, err = ParseMesonAmount("1.0000001")
+	require.Error(t, err)
+	_, err = ParseMesonAmount("-1")
+	require.Error(t, err)
+	_, err = ParseMesonAmount("abc")
+	require.Error(t, err)
+
+	base, _ := new(big.Int).SetString("123456789012345678901", 10)
+	amount = TokenAmountFromBase(base, 18)
+	require.Equal(t, "123.456789012345678901", amount.String())
+	require.Equal(t, base, amount.BaseUnits())
+}
+
+func TestTokenAmount_JSON(t *testing.T) {
+	for _, tt := range []struct {
+		value    string
+		decimals int32
+	}{{"1", 18}, {"0.000000000000000001", 18}, {"6", MesonDecimals}, {"12.5", 8}} {
+		amount := mustTokenAmount(t, tt.value, tt.decimals)
+		data, err := json.Marshal(amount)
+		require.NoError(t, err)
+
+		var decoded TokenAmount
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		require.Equal(t, amount.BaseUnits(), decoded.BaseUnits(), string(data))
+		require.Equal(t, amount.String(), decoded.String())
+	}
+
+	// 经过JSON往返的路径保持18位精度
+	leg := &RouteLeg{Amount: mustTokenAmount(t, "1", 18)}
+	data, err := json.Marshal(leg)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"amount":{"value":"1","decimals":18}`)
+	var decodedLeg RouteLeg
+	require.NoError(t, json.Unmarshal(data, &decodedLeg))
+	expected, _ := new(big.Int).SetString("1000000000000000000", 10)
+	require.Equal(t, expected, decodedLeg.Amount.BaseUnits())
+
+	// 旧格式的字符串
+	var legacy TokenAmount
+	require.NoError(t, json.Unmarshal([]byte(`"1.5"`), &legacy))
+	require.Equal(t, big.NewInt(1500000), legacy.BaseUnits())
+	require.Error(t, json.Unmarshal([]byte(`{"value":"1.5","decimals":0}`), &legacy))
+	require.Error(t, json.Unmarshal([]byte(`42`), &legacy))
+}
@@ -0,0 +1,184 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+)
+
+// DefaultBatchConcurrency 批量转账默认同时进行的转账数
+const DefaultBatchConcurrency = 4
+
+// BatchOptions 批量转账选项
+type BatchOptions struct {
+	Concurrency     int                // 同时进行编码、签名、提交的转账数，为0时使用DefaultBatchConcurrency
+	OnResult        func(*BatchResult) // 每完成一项时回调，可用于记录进度，可能被并发调用
+	ApproveMaxValue bool               // 授权最大值而不是本批次的总金额
+}
+
+// BatchResult 批量转账中单项的结果
+type BatchResult struct {
+	Index   int              // 在请求列表中的位置
+	Request *TransferRequest // 原始请求
+	Record  *SwapRecord      // 转账记录，创建前失败时为nil
+	Err     error
+}
+
+// SetRelayerRateLimit 设置请求中继器的最小间隔，批量转账时避免触发频率限制
+func (b *Bridge) SetRelayerRateLimit(interval time.Duration) {
+	b.client.SetRateLimit(interval)
+}
+
+// TransferBatch 批量跨链转账: 按源链和代币合并授权，每组只发送一笔approve，
+// 之后以有限并发依次编码、签名、提交。单项失败不影响其他项，结果与请求一一对应
+func (b *Bridge) TransferBatch(ctx context.Context, sender *helpers.Sender, reqs []*TransferRequest, opts *BatchOptions) []*BatchResult {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	// 金额无效的项单独失败，不计入所在组的授权总额
+	results := make([]*BatchResult, len(reqs))
+	var valid []*TransferRequest
+	for i, req := range reqs {
+		results[i] = &BatchResult{Index: i, Request: req}
+		if _, err := req.Amount.MesonAmount(); err != nil {
+			results[i].Err = fmt.Errorf("金额无效: %w", err)
+			continue
+		}
+		valid = append(valid, req)
+	}
+	approveErrs := b.approveBatch(ctx, sender, valid, opts.ApproveMaxValue)
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i, req := range reqs {
+		result := results[i]
+		if result.Err != nil {
+			b.batchDone(opts, result)
+			continue
+		}
+		key := ChainTokenKey{Chain: req.FromChain, Token: req.FromToken}
+		if err := approveErrs[key]; err != nil && !req.SkipApprove {
+			result.Err = fmt.Errorf("授权失败: %w", err)
+			b.batchDone(opts, result)
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			result.Err = ctx.Err()
+			b.batchDone(opts, result)
+			continue
+		}
+		wg.Add(1)
+		go func(req TransferRequest) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			// 授权已按组完成
+			req.SkipApprove = true
+			result.Record, result.Err = b.Transfer(ctx, sender, &req)
+			b.batchDone(opts, result)
+		}(*req)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// approveBatch 按源链和代币汇总金额，授权额度不足时发送一笔approve，返回各组的授权错误
+func (b *Bridge) approveBatch(ctx context.Context, sender *helpers.Sender, reqs []*TransferRequest, maxValue bool) map[ChainTokenKey]error {
+	var keys []ChainTokenKey
+	totals := make(map[ChainTokenKey]decimal.Decimal)
+	for _, req := range reqs {
+		if req.SkipApprove {
+			continue
+		}
+		key := ChainTokenKey{Chain: req.FromChain, Token: req.FromToken}
+		if _, ok := totals[key]; !ok {
+			keys = append(keys, key)
+		}
+		totals[key] = totals[key].Add(req.Amount.Decimal())
+	}
+
+	errs := make(map[ChainTokenKey]error)
+	for _, key := range keys {
+		total, err := NewTokenAmount(totals[key], MesonDecimals)
+		if err == nil {
+			err = b.approveTotal(ctx, sender, key, total, maxValue)
+		}
+		if err != nil {
+			b.logf("授权%s链代币%s失败: %v\n", key.Chain, key.Token, err)
+			errs[key] = err
+		}
+	}
+	return errs
+}
+
+// approveTotal 授权额度不足total时发送approve交易并等待确认
+func (b *Bridge) approveTotal(ctx context.Context, sender *helpers.Sender, key ChainTokenKey, total TokenAmount, maxValue bool) error {
+	client, err := b.clientFor(key.Chain)
+	if err != nil {
+		return err
+	}
+	tokenAddr, err := b.tokenAddress(key.Chain, key.Token)
+	if err != nil {
+		return err
+	}
+	poolAddr, exists := b.poolAddrs[key.Chain]
+	if !exists {
+		return fmt.Errorf("未知的链: %s，请先注册池地址", key.Chain)
+	}
+	erc20, err := NewERC20(client, tokenAddr)
+	if err != nil {
+		return err
+	}
+	decimals, err := erc20.Decimals(ctx)
+	if err != nil {
+		return fmt.Errorf("查询代币精度失败: %w", err)
+	}
+	needed, err := total.ToDecimals(int32(decimals))
+	if err != nil {
+		return err
+	}
+	allowance, err := erc20.Allowance(ctx, sender.From(), poolAddr)
+	if err != nil {
+		return fmt.Errorf("查询授权额度失败: %w", err)
+	}
+	if allowance.Cmp(needed.BaseUnits()) >= 0 {
+		return nil
+	}
+
+	var txData *helpers.TxData
+	if maxValue {
+		txData, err = b.GetApproveData(ctx, sender.From().Hex(), key.Chain, key.Token, "")
+	} else {
+		txData, err = b.GetApproveAmountData(ctx, key.Chain, key.Token, total)
+	}
+	if err != nil {
+		return err
+	}
+	result, err := sender.Send(ctx, txData)
+	if err != nil {
+		return err
+	}
+	b.logf("已授权%s链代币%s共%s，交易哈希: %s\n", key.Chain, key.Token, total, result.Hash.Hex())
+	return nil
+}
+
+// batchDone 回调单项结果
+func (b *Bridge) batchDone(opts *BatchOptions, result *BatchResult) {
+	if opts.OnResult != nil {
+		opts.OnResult(result)
+	}
+}
@@ -0,0 +1,72 @@
+package meson
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+)
+
+func TestTransferBatch_ApproveFailure(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := helpers.NewSender(nil, big.NewInt(4200), key)
+
+	// 未连接任何链，授权失败的组中每一项都返回错误，且不影响回调
+	bridge := NewBridge()
+	reqs := []*TransferRequest{
+		{FromChain: ChainMerlin, ToChain: "bnb", FromToken: TokenMERL, ToToken: TokenMERL, Amount: mustTokenAmount(t, "1", MesonDecimals)},
+		{FromChain: ChainMerlin, ToChain: "bnb", FromToken: TokenMERL, ToToken: TokenMERL, Amount: mustTokenAmount(t, "2", MesonDecimals)},
+	}
+	var done int32
+	results := bridge.TransferBatch(context.Background(), sender, reqs, &BatchOptions{
+		OnResult: func(*BatchResult) { atomic.AddInt32(&done, 1) },
+	})
+	require.Len(t, results, 2)
+	for i, result := range results {
+		require.Equal(t, i, result.Index)
+		require.ErrorContains(t, result.Err, "授权失败")
+		require.Nil(t, result.Record)
+	}
+	require.Equal(t, int32(2), done)
+}
+
+func TestTransferBatch_InvalidAmountFailsOnlyItem(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := helpers.NewSender(nil, big.NewInt(4200), key)
+
+	// 超过6位小数的项单独失败，同组其他项的授权不受其影响
+	bridge := NewBridge()
+	reqs := []*TransferRequest{
+		{FromChain: ChainMerlin, ToChain: "bnb", FromToken: TokenMERL, ToToken: TokenMERL, Amount: mustTokenAmount(t, "1", 18)},
+		{FromChain: ChainMerlin, ToChain: "bnb", FromToken: TokenMERL, ToToken: TokenMERL, Amount: mustTokenAmount(t, "1.1234567", 18)},
+	}
+	var done int32
+	results := bridge.TransferBatch(context.Background(), sender, reqs, &BatchOptions{
+		OnResult: func(*BatchResult) { atomic.AddInt32(&done, 1) },
+	})
+	require.Len(t, results, 2)
+	require.ErrorContains(t, results[1].Err, "金额无效")
+	require.ErrorContains(t, results[1].Err, "超过代币精度")
+	require.ErrorContains(t, results[0].Err, "授权失败")
+	require.NotContains(t, results[0].Err.Error(), "超过代币精度")
+	require.Equal(t, int32(2), done)
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	client := NewClient()
+	client.SetRateLimit(20 * time.Millisecond)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		client.wait()
+	}
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
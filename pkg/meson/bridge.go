@@ -3,12 +3,15 @@ package meson
 import (
 	"context"
 	"fmt"
+	"io"
 	"math/big"
+	"os"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"github.com/shopspring/decimal"
 
 	"github.com/mer-coder/meson-bridge/pkg/helpers"
 )
@@ -32,6 +35,13 @@ const (
 	PoolAddress = "0x25aB3Efd52e6470681CE037cD546Dc60726948D3" // Merlin链上的池地址
 )
 
+// 跨链交易的过期时间，池合约要求过期时间距提交时在MinBondPeriod和MaxBondPeriod之间
+const (
+	MinBondPeriod     = time.Hour
+	MaxBondPeriod     = 2 * time.Hour
+	DefaultSwapExpiry = 110 * time.Minute
+)
+
 // 添加一个TokenAddress映射，按链和代币类型存储地址
 var TokenAddressMap = map[ChainTokenKey]string{
 	{Chain: ChainMerlin, Token: TokenMBTC}: MBTCAddress,
@@ -39,13 +49,30 @@ var TokenAddressMap = map[ChainTokenKey]string{
 	// 其他链上的代币地址可以在此添加
 }
 
+// ChainCodeMap encoded中的链编号(SLIP-44币种编号)与链标识的对应关系
+// 其他链可以通过RegisterChainCode注册
+var ChainCodeMap = map[uint16]Chain{
+	0x003c: "eth",
+	0x02ca: "bnb",
+	0x0324: ChainZksync,
+	0x1068: ChainMerlin,
+}
+
 // Bridge Meson跨链桥操作封装
 type Bridge struct {
 	client       *Client
 	ethClient    *ethclient.Client
 	currentChain Chain                            // 当前连接的链
+	ethClients   map[Chain]*ethclient.Client      // 按链存储的以太坊客户端，用于跨链查询和目标链操作
 	tokenAddrs   map[ChainTokenKey]common.Address // 按链和代币类型存储地址
 	poolAddrs    map[Chain]common.Address         // 按链存储池地址
+	chainCodes   map[uint16]Chain                 // encoded中的链编号到链标识
+	store        SwapStore                        // 跨链转账记录
+	keyLocks     keyLocker                        // 按幂等键加锁，防止同一键并发执行
+	nativePrices sync.Map                         // 链到原生代币价格(decimal.Decimal)，用于比较路径
+	logOut       io.Writer                        // 日志输出，默认为标准错误
+	listenersMu  sync.RWMutex
+	listeners    []func(*PhaseChange) // 阶段变化回调
 	initialized  bool
 }
 
@@ -55,9 +82,23 @@ func NewBridge() *Bridge {
 		client:     NewClient(),
 		tokenAddrs: make(map[ChainTokenKey]common.Address),
 		poolAddrs:  make(map[Chain]common.Address),
+		ethClients: make(map[Chain]*ethclient.Client),
+		chainCodes: make(map[uint16]Chain),
+		store:      NewMemoryStore(),
+		logOut:     os.Stderr,
 	}
 }
 
+// SetLogOutput 设置日志输出，默认为标准错误
+func (b *Bridge) SetLogOutput(w io.Writer) {
+	b.logOut = w
+}
+
+// logf 输出日志
+func (b *Bridge) logf(format string, args ...interface{}) {
+	helpers.Logf(b.logOut, format, args...)
+}
+
 // InitEthClient 初始化以太坊客户端
 func (b *Bridge) InitEthClient(url string, chain Chain) error {
 	client, err := ethclient.Dial(url)
@@ -67,6 +108,7 @@ func (b *Bridge) InitEthClient(url string, chain Chain) error {
 	b.ethClient = client
 	b.initialized = true
 	b.currentChain = chain
+	b.ethClients[chain] = client
 
 	// 默认初始化已知Token地址
 	for key, addr := range TokenAddressMap {
@@ -79,6 +121,22 @@ func (b *Bridge) InitEthClient(url string, chain Chain) error {
 	return nil
 }
 
+// AddChainClient 连接其他链的以太坊客户端，用于查询目标链状态或在目标链上发送交易
+// 不改变当前连接的链
+func (b *Bridge) AddChainClient(url string, chain Chain) error {
+	client, err := ethclient.Dial(url)
+	if err != nil {
+		return fmt.Errorf("连接%s链节点失败: %w", chain, err)
+	}
+	b.ethClients[chain] = client
+	return nil
+}
+
+// EthClient 返回当前连接链的以太坊客户端，未初始化时为nil
+func (b *Bridge) EthClient() *ethclient.Client {
+	return b.ethClient
+}
+
 // RegisterTokenAddress 注册指定链上的Token地址
 func (b *Bridge) RegisterTokenAddress(chain Chain, token Token, address string) error {
 	if !common.IsHexAddress(address) {
@@ -97,8 +155,29 @@ func (b *Bridge) RegisterPoolAddress(chain Chain, address string) error {
 	return nil
 }
 
+// RegisterChainCode 注册encoded中的链编号(SLIP-44币种编号)对应的链
+func (b *Bridge) RegisterChainCode(chain Chain, code uint16) {
+	b.chainCodes[code] = chain
+}
+
+// chainByCode 根据encoded中的链编号查找链标识
+func (b *Bridge) chainByCode(code uint16) (Chain, error) {
+	if chain, ok := b.chainCodes[code]; ok {
+		return chain, nil
+	}
+	if chain, ok := ChainCodeMap[code]; ok {
+		return chain, nil
+	}
+	return "", fmt.Errorf("未知的链编号: %#04x，请先调用RegisterChainCode注册", code)
+}
+
 // BridgeMBTC 从Merlin跨链MBTC到Linea
-func (b *Bridge) BridgeMBTC(ctx context.Context, amount decimal.Decimal, fromAddr, toAddr string, fromChain, toChain Chain, fromToken, toToken Token) (*SwapEncodeResponse, error) {
+func (b *Bridge) BridgeMBTC(ctx context.Context, amount TokenAmount, fromAddr, toAddr string, fromChain, toChain Chain, fromToken, toToken Token) (*SwapEncodeResponse, error) {
+	return b.encodeSwap(ctx, amount, fromAddr, toAddr, fromChain, toChain, fromToken, toToken, DefaultSwapExpiry)
+}
+
+// encodeSwap 由中继器编码跨链交易，交易在expireIn之后过期
+func (b *Bridge) encodeSwap(ctx context.Context, amount TokenAmount, fromAddr, toAddr string, fromChain, toChain Chain, fromToken, toToken Token, expireIn time.Duration) (*SwapEncodeResponse, error) {
 	// 验证参数
 	if err := b.validateAddresses(fromAddr, toAddr); err != nil {
 		return nil, err
@@ -117,14 +196,20 @@ func (b *Bridge) BridgeMBTC(ctx context.Context, amount decimal.Decimal, fromAdd
 		toToken = "67"
 	}
 
+	// Meson只支持6位小数，多余的精度会被中继器拒绝
+	mesonAmount, err := amount.MesonAmount()
+	if err != nil {
+		return nil, err
+	}
+
 	// 编码跨链交易
 	encodeResp, err := b.client.EncodeSwap(&SwapEncodeRequest{
 		From:        fmt.Sprintf("%s:%s", fromChain, fromToken),
 		To:          fmt.Sprintf("%s:%s", toChain, toToken),
-		Amount:      amount.String(),
+		Amount:      mesonAmount.String(),
 		FromAddress: fromAddr,
 		Recipient:   toAddr,
-		ExpireTs:    time.Now().Add(time.Minute * 110).Unix(), // 返回的是Unix时间戳(秒数),例如1704074400表示2024-01-01 02:00:00 UTC
+		ExpireTs:    time.Now().Add(expireIn).Unix(), // 返回的是Unix时间戳(秒数),例如1704074400表示2024-01-01 02:00:00 UTC
 	})
 	if err != nil {
 		return nil, fmt.Errorf("编码交易失败: %w", err)
@@ -133,6 +218,15 @@ func (b *Bridge) BridgeMBTC(ctx context.Context, amount decimal.Decimal, fromAdd
 	return encodeResp, nil
 }
 
+// tokenAddress 获取指定链上已注册的代币地址
+func (b *Bridge) tokenAddress(chain Chain, token Token) (common.Address, error) {
+	addr, exists := b.tokenAddrs[ChainTokenKey{Chain: chain, Token: token}]
+	if !exists {
+		return common.Address{}, fmt.Errorf("未知的代币类型: %s 在链 %s 上，请提供代币地址", token, chain)
+	}
+	return addr, nil
+}
+
 // validateAddresses 验证地址格式
 func (b *Bridge) validateAddresses(addresses ...string) error {
 	for _, addr := range addresses {
@@ -162,7 +256,137 @@ func (b *Bridge) GetSwapStatus(swapId string) (map[string]any, error) {
 	return b.client.GetSwapStatus(swapId)
 }
 
-// GetApproveData 获取approve调用数据，并检查现有授权
+// GetPrice 查询跨链手续费，金额超过Meson的6位精度时返回错误
+func (b *Bridge) GetPrice(amount TokenAmount, fromAddr string, fromChain, toChain Chain, fromToken, toToken Token) (*PriceResponse, error) {
+	mesonAmount, err := amount.MesonAmount()
+	if err != nil {
+		return nil, err
+	}
+	return b.client.GetPrice(&PriceRequest{
+		From:        fmt.Sprintf("%s:%s", fromChain, fromToken),
+		To:          fmt.Sprintf("%s:%s", toChain, toToken),
+		Amount:      mesonAmount.String(),
+		FromAddress: fromAddr,
+	})
+}
+
+// GetApproveAmountData 获取只授权指定金额的approve调用数据
+// 按代币在链上的精度换算为最小单位，金额超过代币精度时返回错误
+func (b *Bridge) GetApproveAmountData(ctx context.Context, chain Chain, token Token, amount TokenAmount) (*helpers.TxData, error) {
+	if chain == "" {
+		chain = b.currentChain
+	}
+	client, err := b.clientFor(chain)
+	if err != nil {
+		return nil, err
+	}
+	tokenAddr, err := b.tokenAddress(chain, token)
+	if err != nil {
+		return nil, err
+	}
+	poolAddr, exists := b.poolAddrs[chain]
+	if !exists {
+		return nil, fmt.Errorf("未知的链: %s，请先注册池地址", chain)
+	}
+
+	erc20, err := NewERC20(client, tokenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("创建ERC20接口失败: %w", err)
+	}
+	decimals, err := erc20.Decimals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询代币精度失败: %w", err)
+	}
+	tokenAmount, err := amount.ToDecimals(int32(decimals))
+	if err != nil {
+		return nil, err
+	}
+
+	approveData, err := erc20.GetApproveData(poolAddr, tokenAmount.BaseUnits())
+	if err != nil {
+		return nil, fmt.Errorf("生成approve数据失败: %w", err)
+	}
+	return &helpers.TxData{
+		To:    tokenAddr,
+		Data:  approveData,
+		Value: big.NewInt(0),
+	}, nil
+}
+
+// GetAllowance 查询owner对池合约的代币授权额度
+func (b *Bridge) GetAllowance(ctx context.Context, chain Chain, token Token, owner string) (TokenAmount, error) {
+	if !common.IsHexAddress(owner) {
+		return TokenAmount{}, fmt.Errorf("无效的地址格式: %s", owner)
+	}
+	if chain == "" {
+		chain = b.currentChain
+	}
+	client, err := b.clientFor(chain)
+	if err != nil {
+		return TokenAmount{}, err
+	}
+	tokenAddr, err := b.tokenAddress(chain, token)
+	if err != nil {
+		return TokenAmount{}, err
+	}
+	poolAddr, exists := b.poolAddrs[chain]
+	if !exists {
+		return TokenAmount{}, fmt.Errorf("未知的链: %s，请先注册池地址", chain)
+	}
+
+	erc20, err := NewERC20(client, tokenAddr)
+	if err != nil {
+		return TokenAmount{}, fmt.Errorf("创建ERC20接口失败: %w", err)
+	}
+	decimals, err := erc20.Decimals(ctx)
+	if err != nil {
+		return TokenAmount{}, fmt.Errorf("查询代币精度失败: %w", err)
+	}
+	allowance, err := erc20.Allowance(ctx, common.HexToAddress(owner), poolAddr)
+	if err != nil {
+		return TokenAmount{}, err
+	}
+	return TokenAmountFromBase(allowance, int32(decimals)), nil
+}
+
+// Chains 返回已注册池地址或已连接的链
+func (b *Bridge) Chains() []Chain {
+	seen := make(map[Chain]bool)
+	var chains []Chain
+	add := func(chain Chain) {
+		if chain != "" && !seen[chain] {
+			seen[chain] = true
+			chains = append(chains, chain)
+		}
+	}
+	for chain := range b.poolAddrs {
+		add(chain)
+	}
+	for chain := range b.ethClients {
+		add(chain)
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i] < chains[j] })
+	return chains
+}
+
+// PoolAddress 返回指定链上注册的池地址
+func (b *Bridge) PoolAddress(chain Chain) (common.Address, bool) {
+	addr, ok := b.poolAddrs[chain]
+	return addr, ok
+}
+
+// Tokens 返回已注册的代币地址
+func (b *Bridge) Tokens() map[ChainTokenKey]common.Address {
+	tokens := make(map[ChainTokenKey]common.Address, len(b.tokenAddrs))
+	for key, addr := range b.tokenAddrs {
+		tokens[key] = addr
+	}
+	return tokens
+}
+
+// GetApproveData 获取授权池合约使用最大额度的approve调用数据
+// 这里不查询现有授权: 调用方可能需要在授权不足之外的情况下重新授权(如approve命令)，
+// 需要按额度决定是否授权时先调用GetAllowance，Preflight和批量转账都是这样处理的
 // fromAddress: 用户地址
 // chain: 链标识，如ChainMerlin
 // token: 代币类型，如TokenMBTC或TokenMERL
@@ -206,10 +430,6 @@ func (b *Bridge) GetApproveData(ctx context.Context, fromAddress string, chain C
 		return nil, fmt.Errorf("未知的链: %s，请先注册池地址", chain)
 	}
 
-	// 由于Allowance方法存在问题，我们直接生成approve交易
-	// 在实际应用中，最好先检查授权
-	// 为了简化，我们假设需要授权
-
 	// 创建ERC20接口
 	erc20, err := NewERC20(b.ethClient, tokenAddr)
 	if err != nil {
@@ -219,18 +439,100 @@ func (b *Bridge) GetApproveData(ctx context.Context, fromAddress string, chain C
 	// 定义最大值用于授权
 	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
 
-	// 简化处理: 由于无法检查allowance，我们总是生成approve数据
-	// 在实际应用中，最好实现allowance检查
 	approveData, err := erc20.GetApproveData(poolAddr, maxUint256)
 	if err != nil {
 		return nil, fmt.Errorf("生成approve数据失败: %w", err)
 	}
 
-	// 为安全起见，我们总是返回approve数据
-	// 在实际应用中，返回的第三个值应该基于allowance检查
 	return &helpers.TxData{
 		To:   tokenAddr,
 		Data: approveData,
 	}, nil
 }
 
+// GetPostSwapData 获取在源链池合约上直接提交跨链交易(postSwap)的调用数据
+// encoded为中继器编码的跨链交易，fromAddr为签名的发起人，sig为对SigningRequest.Hash的签名
+func (b *Bridge) GetPostSwapData(chain Chain, encoded, fromAddr string, sig []byte) (*helpers.TxData, error) {
+	if err := b.validateAddresses(fromAddr); err != nil {
+		return nil, err
+	}
+	swap, err := DecodeEncodedSwap(encoded)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := b.pool(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := pool.GetPostSwapData(swap.Int(), sig, common.HexToAddress(fromAddr), 0)
+	if err != nil {
+		return nil, fmt.Errorf("生成postSwap数据失败: %w", err)
+	}
+	return &helpers.TxData{
+		To:   pool.Address(),
+		Data: data,
+	}, nil
+}
+
+// PostSwapOnChain 不经过中继器，直接在源链池合约上提交已签名的跨链交易
+// 适用于中继器不可用的情况，交易提交后等待LP绑定并完成跨链
+func (b *Bridge) PostSwapOnChain(ctx context.Context, sender *helpers.Sender, chain Chain, encoded, fromAddr string, sig []byte) (*helpers.TxResult, error) {
+	txData, err := b.GetPostSwapData(chain, encoded, fromAddr, sig)
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一笔交易只能提交一次
+	pool, err := b.pool(chain)
+	if err != nil {
+		return nil, err
+	}
+	swap, err := DecodeEncodedSwap(encoded)
+	if err != nil {
+		return nil, err
+	}
+	posted, err := pool.GetPostedSwap(ctx, swap.Int())
+	if err != nil {
+		return nil, fmt.Errorf("查询链上交易失败: %w", err)
+	}
+	if posted.Exist {
+		return nil, fmt.Errorf("跨链交易已在链上提交: %s", encoded)
+	}
+
+	result, err := sender.Send(ctx, txData)
+	if err != nil {
+		return result, fmt.Errorf("提交链上交易失败: %w", err)
+	}
+	return result, nil
+}
+
+// pool 获取指定链的池合约接口
+func (b *Bridge) pool(chain Chain) (*MesonPool, error) {
+	if chain == "" {
+		chain = b.currentChain
+	}
+	client, err := b.clientFor(chain)
+	if err != nil {
+		return nil, err
+	}
+	poolAddr, exists := b.poolAddrs[chain]
+	if !exists {
+		return nil, fmt.Errorf("未知的链: %s，请先注册池地址", chain)
+	}
+	return NewMesonPool(client, poolAddr)
+}
+
+// clientFor 获取指定链的以太坊客户端，chain为空时使用当前连接的链
+func (b *Bridge) clientFor(chain Chain) (*ethclient.Client, error) {
+	if chain == "" {
+		chain = b.currentChain
+	}
+	if client, ok := b.ethClients[chain]; ok {
+		return client, nil
+	}
+	if !b.initialized || b.ethClient == nil {
+		return nil, fmt.Errorf("以太坊客户端未初始化，请先调用InitEthClient")
+	}
+	return nil, fmt.Errorf("未连接链%s，请先调用AddChainClient", chain)
+}
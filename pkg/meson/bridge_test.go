@@ -12,7 +12,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/require"
 
 	"github.com/mer-coder/meson-bridge/pkg/helpers"
@@ -94,7 +93,8 @@ func TestBridge_Bridge(t *testing.T) {
 	t.Logf("Approve tx hash: %s", approveHash)
 
 	// 2. 获取待签名消息
-	amount := decimal.NewFromFloat(6)
+	amount, err := ParseMesonAmount("6")
+	require.NoError(t, err)
 	resp, err := bridge.BridgeMBTC(context.Background(), amount, fromAddr, toAddr, ChainMerlin, "bnb", TokenMERL, TokenMERL)
 	require.NoError(t, err)
 	require.NotEmpty(t, resp)
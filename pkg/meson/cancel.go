@@ -0,0 +1,186 @@
+package meson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+)
+
+// ErrSwapNotExpired 跨链交易尚未过期，不能取消
+var ErrSwapNotExpired = errors.New("跨链交易尚未过期")
+
+// CancelResult 取消过期跨链交易的结果
+type CancelResult struct {
+	Encoded   string            // 被取消的跨链交易
+	Initiator common.Address    // 发起人，退款转回该地址
+	Amount    TokenAmount       // 退回的金额
+	ExpiredAt time.Time         // 过期时间
+	Tx        *helpers.TxResult // cancelSwap交易结果
+}
+
+// CancelExpiredSwap 取消已过期且未完成的跨链交易，将资金退回发起人
+// ref可以是中继器返回的swapId，也可以是encoded；chain为源链，为空时使用当前连接的链
+func (b *Bridge) CancelExpiredSwap(ctx context.Context, sender *helpers.Sender, chain Chain, ref string) (*CancelResult, error) {
+	swap, err := b.resolveEncodedSwap(ref)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := b.pool(chain)
+	if err != nil {
+		return nil, err
+	}
+	return b.cancelSwap(ctx, sender, pool, swap)
+}
+
+// FindExpiredSwaps 查找发起人在源链上所有已过期且未完成的跨链交易
+// 从fromBlock开始扫描SwapPosted事件，逐个检查链上状态
+func (b *Bridge) FindExpiredSwaps(ctx context.Context, chain Chain, initiator string, fromBlock uint64) ([]*EncodedSwap, error) {
+	if err := b.validateAddresses(initiator); err != nil {
+		return nil, err
+	}
+	pool, err := b.pool(chain)
+	if err != nil {
+		return nil, err
+	}
+	client, err := b.clientFor(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	head, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取最新区块失败: %w", err)
+	}
+	encodedSwaps, err := pool.FilterSwapPosted(ctx, fromBlock, head.Number.Uint64(), DefaultLogChunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	initiatorAddr := common.HexToAddress(initiator)
+	var expired []*EncodedSwap
+	for _, swap := range expiredSwaps(encodedSwaps, head.Time) {
+		posted, err := pool.GetPostedSwap(ctx, swap.Int())
+		if err != nil {
+			return nil, fmt.Errorf("查询链上交易失败: %w", err)
+		}
+		if posted.Exist && posted.Initiator == initiatorAddr {
+			expired = append(expired, swap)
+		}
+	}
+	return expired, nil
+}
+
+// expiredSwaps 解码SwapPosted事件中的交易，返回在给定区块时间已过期的部分
+func expiredSwaps(encodedSwaps []*big.Int, blockTime uint64) []*EncodedSwap {
+	var expired []*EncodedSwap
+	for _, encodedSwap := range encodedSwaps {
+		swap, err := DecodeEncodedSwap(hexEncoded(encodedSwap))
+		if err != nil {
+			continue
+		}
+		if swap.ExpiredAt(blockTime) {
+			expired = append(expired, swap)
+		}
+	}
+	return expired
+}
+
+// SweepExpiredSwaps 取消发起人在源链上所有已过期的跨链交易
+// 单笔取消失败不影响其他交易，失败的交易记录在返回的错误映射中(key为encoded)
+func (b *Bridge) SweepExpiredSwaps(ctx context.Context, sender *helpers.Sender, chain Chain, initiator string, fromBlock uint64) ([]*CancelResult, map[string]error, error) {
+	expired, err := b.FindExpiredSwaps(ctx, chain, initiator, fromBlock)
+	if err != nil {
+		return nil, nil, err
+	}
+	pool, err := b.pool(chain)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var results []*CancelResult
+	failures := make(map[string]error)
+	for _, swap := range expired {
+		result, err := b.cancelSwap(ctx, sender, pool, swap)
+		if err != nil {
+			failures[swap.Hex()] = err
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, failures, nil
+}
+
+// cancelSwap 检查链上状态和过期时间后发送cancelSwap交易
+func (b *Bridge) cancelSwap(ctx context.Context, sender *helpers.Sender, pool *MesonPool, swap *EncodedSwap) (*CancelResult, error) {
+	posted, err := pool.GetPostedSwap(ctx, swap.Int())
+	if err != nil {
+		return nil, fmt.Errorf("查询链上交易失败: %w", err)
+	}
+	if !posted.Exist {
+		return nil, fmt.Errorf("%w: %s", errSwapNotPosted, swap.Hex())
+	}
+
+	// 以链上区块时间判断是否过期
+	head, err := pool.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取最新区块失败: %w", err)
+	}
+	if !swap.ExpiredAt(head.Time) {
+		return nil, fmt.Errorf("%w，过期时间: %s", ErrSwapNotExpired, swap.ExpireTime().Format(time.RFC3339))
+	}
+
+	data, err := pool.GetCancelSwapData(swap.Int())
+	if err != nil {
+		return nil, fmt.Errorf("生成cancelSwap数据失败: %w", err)
+	}
+	txResult, err := sender.Send(ctx, &helpers.TxData{To: pool.Address(), Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("发送取消交易失败: %w", err)
+	}
+
+	return &CancelResult{
+		Encoded:   swap.Hex(),
+		Initiator: posted.Initiator,
+		Amount:    TokenAmountFromBase(swap.Amount, MesonDecimals),
+		ExpiredAt: swap.ExpireTime(),
+		Tx:        txResult,
+	}, nil
+}
+
+// resolveEncodedSwap 将swapId或encoded解析为跨链交易
+// 能解码为有效交易的视为encoded，否则视为swapId并从中继器查询encoded
+func (b *Bridge) resolveEncodedSwap(ref string) (*EncodedSwap, error) {
+	if swap, err := DecodeEncodedSwap(ref); err == nil && looksLikeEncoded(swap) {
+		return swap, nil
+	}
+
+	status, err := b.client.GetSwapStatus(ref)
+	if err != nil {
+		return nil, fmt.Errorf("查询跨链交易失败: %w", err)
+	}
+	encoded, ok := status["encoded"].(string)
+	if !ok || encoded == "" {
+		return nil, fmt.Errorf("中继器未返回跨链交易的encoded: %s", ref)
+	}
+	return DecodeEncodedSwap(encoded)
+}
+
+// looksLikeEncoded 判断解码结果是否像一笔真实的跨链交易(版本号和过期时间合理)
+func looksLikeEncoded(swap *EncodedSwap) bool {
+	const (
+		minExpireTs = 1577836800 // 2020-01-01
+		maxExpireTs = 4102444800 // 2100-01-01
+	)
+	return (swap.Version == 1 || swap.Version == 2) && swap.ExpireTs > minExpireTs && swap.ExpireTs < maxExpireTs
+}
+
+// hexEncoded 将uint256形式的encoded转换为十六进制字符串
+func hexEncoded(encodedSwap *big.Int) string {
+	return common.BigToHash(encodedSwap).Hex()
+}
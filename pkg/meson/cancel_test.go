@@ -0,0 +1,86 @@
+package meson
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+// encodedAt 生成指定版本和过期时间的encoded
+func encodedAt(version uint8, expireTs int64) string {
+	return fmt.Sprintf("0x%02x%010x%s%010x%010x%04x%02x%04x%02x", version, 6000000, "c00000000000e7552620", 0, expireTs, 0x02ca, 69, 0x1068, 69)
+}
+
+func TestLooksLikeEncoded(t *testing.T) {
+	tests := []struct {
+		name string
+		ref  string
+		want bool
+	}{
+		{"v1", encodedAt(1, 0x6592116a), true},
+		{"v2", encodedAt(2, 0x6592116a), true},
+		{"version 0", encodedAt(0, 0x6592116a), false},
+		{"version 3", encodedAt(3, 0x6592116a), false},
+		{"expire before 2020", encodedAt(1, 1500000000), false},
+		{"expire after 2100", encodedAt(1, 4200000000), false},
+		{"swap id", "0x" + common.Bytes2Hex(common.LeftPadBytes([]byte{0xff, 0xee}, 32)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			swap, err := DecodeEncodedSwap(tt.ref)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, looksLikeEncoded(swap))
+		})
+	}
+}
+
+func TestResolveEncodedSwap(t *testing.T) {
+	b := NewBridge()
+	encoded := encodedAt(1, 0x6592116a)
+	swap, err := b.resolveEncodedSwap(encoded)
+	require.NoError(t, err)
+	require.Equal(t, encoded, swap.Hex())
+}
+
+func TestExpiredSwaps(t *testing.T) {
+	const now = 1700000000
+	swapAt := func(expireTs int64) *big.Int {
+		swap, err := DecodeEncodedSwap(encodedAt(1, expireTs))
+		require.NoError(t, err)
+		return swap.Int()
+	}
+
+	tests := []struct {
+		name    string
+		swaps   []*big.Int
+		expired []int64
+	}{
+		{"none", nil, nil},
+		{"expired", []*big.Int{swapAt(now - 1)}, []int64{now - 1}},
+		{"expires at block time", []*big.Int{swapAt(now)}, nil},
+		{"not expired", []*big.Int{swapAt(now + 1)}, nil},
+		{"mixed", []*big.Int{swapAt(now + 3600), swapAt(now - 3600), swapAt(now - 60)}, []int64{now - 3600, now - 60}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int64
+			for _, swap := range expiredSwaps(tt.swaps, now) {
+				got = append(got, swap.ExpireTs)
+			}
+			require.Equal(t, tt.expired, got)
+		})
+	}
+}
+
+func TestEncodedSwap_ExpiredAt(t *testing.T) {
+	swap, err := DecodeEncodedSwap(encodedAt(1, 1700000000))
+	require.NoError(t, err)
+	require.False(t, swap.ExpiredAt(1699999999))
+	require.False(t, swap.ExpiredAt(1700000000))
+	require.True(t, swap.ExpiredAt(1700000001))
+	require.True(t, swap.ExpiredAt(uint64(time.Now().Unix())))
+}
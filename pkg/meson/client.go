@@ -6,21 +6,72 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 )
 
 const (
-	baseURL = "https://relayer.meson.fi/api/v1"
+	defaultBaseURL = "https://relayer.meson.fi/api/v1"
+	// rateLimitRetries 中继器返回429时的最大重试次数
+	rateLimitRetries = 3
 )
 
+// APIError 中继器请求失败时返回的错误
+type APIError struct {
+	StatusCode int    // HTTP状态码，请求未送达时为0
+	Body       string // 响应内容
+	Err        error  // 请求未送达时的原因
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("发送请求失败: %v", e.Err)
+	}
+	return fmt.Sprintf("API请求失败,状态码:%d,响应:%s", e.StatusCode, e.Body)
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
 // Client Meson API客户端封装
 type Client struct {
 	httpClient *http.Client
+	baseURL    string
+
+	mu       sync.Mutex
+	interval time.Duration // 两次请求之间的最小间隔
+	next     time.Time     // 下一次允许发送请求的时间
+}
+
+// SetRateLimit 设置两次请求之间的最小间隔，为0时不限制
+// 并发调用时请求会排队，避免触发中继器的频率限制
+func (c *Client) SetRateLimit(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interval = interval
+}
+
+// wait 等待到允许发送下一次请求的时间
+func (c *Client) wait() {
+	c.mu.Lock()
+	now := time.Now()
+	at := c.next
+	if at.Before(now) {
+		at = now
+	}
+	c.next = at.Add(c.interval)
+	c.mu.Unlock()
+
+	time.Sleep(time.Until(at))
 }
 
 // NewClient 创建API客户端实例
 func NewClient() *Client {
 	return &Client{
 		httpClient: &http.Client{},
+		baseURL:    defaultBaseURL,
 	}
 }
 
@@ -48,39 +99,68 @@ func (c *Client) GetSwapStatus(swapId string) (map[string]any, error) {
 	return *result, nil
 }
 
+// ListChains 获取中继器支持的链、代币及单笔金额限制
+func (c *Client) ListChains() ([]ChainInfo, error) {
+	result, err := doRequest[[]ChainInfo](c, "GET", "/list", nil)
+	if err != nil {
+		return nil, err
+	}
+	return *result, nil
+}
+
 // doRequest 通用请求处理
 func doRequest[T any](c *Client, method, path string, body interface{}) (*T, error) {
-	var reqBody io.Reader
+	var data []byte
 	if body != nil {
-		data, err := json.Marshal(body)
+		var err error
+		data, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("序列化请求失败: %w", err)
 		}
-		reqBody = bytes.NewReader(data)
 	}
 
-	req, err := http.NewRequest(method, baseURL+path, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
+	var (
+		resp     *http.Response
+		respBody []byte
+	)
+	for attempt := 0; ; attempt++ {
+		var reqBody io.Reader
+		if data != nil {
+			reqBody = bytes.NewReader(data)
+		}
+		req, err := http.NewRequest(method, c.baseURL+path, reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("创建请求失败: %w", err)
+		}
 
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
-	}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
-	}
-	defer resp.Body.Close()
+		c.wait()
+		resp, err = c.httpClient.Do(req)
+		if err != nil {
+			return nil, &APIError{Err: err}
+		}
+		respBody, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取响应失败: %w", err)
+		}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+		// 触发频率限制时按Retry-After或指数退避重试
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= rateLimitRetries {
+			break
+		}
+		delay := time.Second << attempt
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			delay = time.Duration(seconds) * time.Second
+		}
+		time.Sleep(delay)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API请求失败,状态码:%d,响应:%s", resp.StatusCode, string(respBody))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
 	}
 
 	var apiResp struct {
@@ -0,0 +1,79 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+)
+
+// ApproveMax 授权最大值，Transfer发送的授权交易即为最大值
+const ApproveMax = "max"
+
+// PreviewTransfer 在发送任何交易之前编码跨链交易并执行预检，查询是否需要授权及授权交易的gas，用于向用户确认
+// 预检发现的问题记录在返回的Problems中。用户确认后将返回的交易设为TransferRequest.Prepared再调用Transfer，
+// 签名的即为确认时展示的encoded(包括手续费、过期时间和salt)
+func (b *Bridge) PreviewTransfer(ctx context.Context, req *TransferRequest, guard *FeeGuard) (*PreparedSwap, error) {
+	prepared, err := b.PrepareSwap(ctx, req, guard)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := b.Preflight(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	prepared.Problems = report.Problems
+	if report.NeedsApprove && !req.SkipApprove {
+		prepared.ApproveAmount = ApproveMax
+		prepared.EstimatedGas = report.EstimatedGas
+	}
+	return prepared, nil
+}
+
+// Describe 返回便于阅读的转账摘要: 源链和目标链代币、金额、接收地址、手续费、授权、预计gas、过期时间和预检发现的问题
+func (p *PreparedSwap) Describe() string {
+	var sb strings.Builder
+	line := func(label, format string, args ...interface{}) {
+		fmt.Fprintf(&sb, "%s: %s\n", label, fmt.Sprintf(format, args...))
+	}
+
+	line("源链", "%s 代币%s", p.FromChain, p.FromToken)
+	line("目标链", "%s 代币%s", p.ToChain, p.ToToken)
+	line("金额", "%s", p.Amount)
+	line("发送地址", "%s", checksumAddress(p.FromAddress))
+	line("接收地址", "%s", checksumAddress(p.Recipient))
+	line("手续费", "服务费 %s，LP手续费 %s，合计 %s", p.Price.ServiceFee, p.Price.LpFee, p.Price.TotalFee)
+	if totalFee, err := decimal.NewFromString(p.Price.TotalFee); err == nil {
+		line("预计到账", "%s", p.Amount.Decimal().Sub(totalFee))
+	}
+	switch p.ApproveAmount {
+	case "":
+		line("授权", "无需授权")
+	case ApproveMax:
+		line("授权", "授权池合约使用最大额度")
+	default:
+		line("授权", "授权池合约使用 %s", p.ApproveAmount)
+	}
+	if p.ApproveAmount != "" {
+		line("预计gas", "%s 原生代币(授权交易)", p.EstimatedGas)
+	} else {
+		line("预计gas", "0 (跨链交易由中继器提交)")
+	}
+	line("过期时间", "%s (%s后)", p.ExpireAt.Local().Format(time.DateTime), time.Until(p.ExpireAt).Round(time.Minute))
+	for _, problem := range p.Problems {
+		line("预检问题", "[%s] %s", problem.Check, problem.Message)
+	}
+	return sb.String()
+}
+
+// checksumAddress 返回EIP-55校验格式的地址，无效地址原样返回
+func checksumAddress(addr string) string {
+	if !common.IsHexAddress(addr) {
+		return addr
+	}
+	return common.HexToAddress(addr).Hex()
+}
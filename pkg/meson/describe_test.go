@@ -0,0 +1,39 @@
+package meson
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreparedSwap_Describe(t *testing.T) {
+	p := &PreparedSwap{
+		FromChain:   ChainMerlin,
+		ToChain:     ChainZksync,
+		FromToken:   "69",
+		ToToken:     "69",
+		Amount:      mustTokenAmount(t, "6", MesonDecimals),
+		FromAddress: "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23",
+		Recipient:   "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23",
+		Price:       PriceResponse{ServiceFee: "0.1", LpFee: "0.2", TotalFee: "0.3"},
+		ExpireAt:    time.Now().Add(time.Hour),
+	}
+	summary := p.Describe()
+	// 地址按EIP-55校验格式显示
+	require.Contains(t, summary, "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	require.Contains(t, summary, "合计 0.3")
+	require.Contains(t, summary, "预计到账: 5.7")
+	require.Contains(t, summary, "无需授权")
+
+	p.ApproveAmount = ApproveMax
+	p.EstimatedGas = decimal.RequireFromString("0.0001")
+	summary = p.Describe()
+	require.Contains(t, summary, "最大额度")
+	require.Contains(t, summary, "0.0001")
+	require.NotContains(t, summary, "预检问题")
+
+	p.Problems = []PreflightProblem{{Check: CheckBalance, Message: "代币余额不足"}}
+	require.Contains(t, p.Describe(), "预检问题: [balance] 代币余额不足")
+}
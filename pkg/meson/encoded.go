@@ -0,0 +1,68 @@
+package meson
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// EncodedSwap 解码后的跨链交易，布局(共32字节):
+// | version | amount | salt | fee | expireTs | outChain | outToken | inChain | inToken |
+// |   1B    |   5B   | 10B  | 5B  |    5B    |    2B    |    1B    |   2B    |   1B    |
+type EncodedSwap struct {
+	Version  uint8
+	Amount   *big.Int // 跨链金额，Meson内部统一使用6位小数
+	Salt     []byte   // 10字节随机数及标志位
+	Fee      *big.Int // LP手续费，6位小数
+	ExpireTs int64    // 过期时间(Unix秒)
+	OutChain uint16   // 目标链(SLIP-44币种编号)
+	OutToken uint8    // 目标链代币索引
+	InChain  uint16   // 源链(SLIP-44币种编号)
+	InToken  uint8    // 源链代币索引
+
+	raw *big.Int
+}
+
+// DecodeEncodedSwap 解码中继器返回的encoded字段
+func DecodeEncodedSwap(encoded string) (*EncodedSwap, error) {
+	data, err := hexutil.Decode(encoded)
+	if err != nil || len(data) != 32 {
+		return nil, fmt.Errorf("无效的encoded: %s", encoded)
+	}
+
+	return &EncodedSwap{
+		Version:  data[0],
+		Amount:   new(big.Int).SetBytes(data[1:6]),
+		Salt:     common.CopyBytes(data[6:16]),
+		Fee:      new(big.Int).SetBytes(data[16:21]),
+		ExpireTs: new(big.Int).SetBytes(data[21:26]).Int64(),
+		OutChain: uint16(data[26])<<8 | uint16(data[27]),
+		OutToken: data[28],
+		InChain:  uint16(data[29])<<8 | uint16(data[30]),
+		InToken:  data[31],
+		raw:      new(big.Int).SetBytes(data),
+	}, nil
+}
+
+// Int 返回合约调用使用的uint256形式
+func (s *EncodedSwap) Int() *big.Int {
+	return new(big.Int).Set(s.raw)
+}
+
+// Hex 返回0x开头的64位十六进制形式
+func (s *EncodedSwap) Hex() string {
+	return hexutil.Encode(common.LeftPadBytes(s.raw.Bytes(), 32))
+}
+
+// ExpireTime 返回过期时间
+func (s *EncodedSwap) ExpireTime() time.Time {
+	return time.Unix(s.ExpireTs, 0)
+}
+
+// ExpiredAt 判断在给定的区块时间交易是否已过期(区块时间严格晚于过期时间)
+func (s *EncodedSwap) ExpiredAt(blockTime uint64) bool {
+	return s.ExpireTs >= 0 && blockTime > uint64(s.ExpireTs)
+}
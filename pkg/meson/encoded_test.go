@@ -0,0 +1,56 @@
+package meson
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeEncodedSwap(t *testing.T) {
+	// version=1, amount=6 MERL, fee=0, expireTs=0x6592116a, outChain=0x02ca, outToken=69, inChain=0x1068, inToken=69
+	encoded := "0x01" + "00005b8d80" + "c00000000000e7552620" + "0000000000" + "006592116a" + "02ca" + "45" + "1068" + "45"
+
+	swap, err := DecodeEncodedSwap(encoded)
+	require.NoError(t, err)
+	require.Equal(t, uint8(1), swap.Version)
+	require.Equal(t, big.NewInt(6000000), swap.Amount)
+	require.Equal(t, int64(0x6592116a), swap.ExpireTs)
+	require.Equal(t, uint16(0x02ca), swap.OutChain)
+	require.Equal(t, uint8(69), swap.OutToken)
+	require.Equal(t, uint16(0x1068), swap.InChain)
+	require.Equal(t, uint8(69), swap.InToken)
+	require.Equal(t, encoded, swap.Hex())
+
+	_, err = DecodeEncodedSwap("0x1234")
+	require.Error(t, err)
+}
+
+func TestSplitSignature(t *testing.T) {
+	sig := make([]byte, 65)
+	sig[0] = 0xaa
+	sig[32] = 0x11
+	sig[64] = 28
+
+	r, yParityAndS, err := splitSignature(sig)
+	require.NoError(t, err)
+	require.Equal(t, byte(0xaa), r[0])
+	require.Equal(t, byte(0x91), yParityAndS[0])
+
+	sig[64] = 27
+	_, yParityAndS, err = splitSignature(sig)
+	require.NoError(t, err)
+	require.Equal(t, byte(0x11), yParityAndS[0])
+
+	_, _, err = splitSignature(sig[:64])
+	require.Error(t, err)
+}
+
+func TestPostingValue(t *testing.T) {
+	initiator := common.HexToAddress("0x2F913C820ed3bEb3a67391a6eFF64E70c4B20b19")
+	value := postingValue(initiator, 3)
+
+	require.Equal(t, uint64(3), new(big.Int).And(value, big.NewInt(0xFFFFFFFFFF)).Uint64())
+	require.Equal(t, initiator, common.BigToAddress(new(big.Int).Rsh(value, 40)))
+}
@@ -6,13 +6,49 @@ import (
 	"math/big"
 	"strings"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
 )
 
-// ERC20简化ABI字符串
+// ERC20简化ABI字符串，包含OpenZeppelin v5的自定义错误
 var erc20ABI = `[
+	{
+		"constant": true,
+		"inputs": [
+			{
+				"name": "account",
+				"type": "address"
+			}
+		],
+		"name": "balanceOf",
+		"outputs": [
+			{
+				"name": "",
+				"type": "uint256"
+			}
+		],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "decimals",
+		"outputs": [
+			{
+				"name": "",
+				"type": "uint8"
+			}
+		],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	},
 	{
 		"constant": true,
 		"inputs": [
@@ -58,9 +94,92 @@ var erc20ABI = `[
 		"payable": false,
 		"stateMutability": "nonpayable",
 		"type": "function"
+	},
+	{
+		"inputs": [
+			{
+				"name": "sender",
+				"type": "address"
+			},
+			{
+				"name": "balance",
+				"type": "uint256"
+			},
+			{
+				"name": "needed",
+				"type": "uint256"
+			}
+		],
+		"name": "ERC20InsufficientBalance",
+		"type": "error"
+	},
+	{
+		"inputs": [
+			{
+				"name": "spender",
+				"type": "address"
+			},
+			{
+				"name": "allowance",
+				"type": "uint256"
+			},
+			{
+				"name": "needed",
+				"type": "uint256"
+			}
+		],
+		"name": "ERC20InsufficientAllowance",
+		"type": "error"
+	},
+	{
+		"inputs": [
+			{
+				"name": "approver",
+				"type": "address"
+			}
+		],
+		"name": "ERC20InvalidApprover",
+		"type": "error"
+	},
+	{
+		"inputs": [
+			{
+				"name": "spender",
+				"type": "address"
+			}
+		],
+		"name": "ERC20InvalidSpender",
+		"type": "error"
+	},
+	{
+		"inputs": [
+			{
+				"name": "sender",
+				"type": "address"
+			}
+		],
+		"name": "ERC20InvalidSender",
+		"type": "error"
+	},
+	{
+		"inputs": [
+			{
+				"name": "receiver",
+				"type": "address"
+			}
+		],
+		"name": "ERC20InvalidReceiver",
+		"type": "error"
 	}
 ]`
 
+func init() {
+	// 注册ERC20自定义错误(OpenZeppelin v5)，用于解码失败交易的revert原因
+	if parsed, err := abi.JSON(strings.NewReader(erc20ABI)); err == nil {
+		helpers.RegisterErrors(parsed)
+	}
+}
+
 // ERC20 是ERC20代币合约的简化接口
 type ERC20 struct {
 	address common.Address
@@ -82,11 +201,31 @@ func NewERC20(client *ethclient.Client, address common.Address) (*ERC20, error)
 	}, nil
 }
 
-// Allowance 获取代币的授权额度 - 简化版，不使用CallContract
+// Allowance 获取代币的授权额度
 func (e *ERC20) Allowance(ctx context.Context, owner, spender common.Address) (*big.Int, error) {
-	// 为了简化，我们将在Bridge中实现一个替代方案，不依赖于此方法
-	// 这里返回一个零值，避免编译错误
-	return big.NewInt(0), nil
+	out, err := e.call(ctx, "allowance", owner, spender)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
+
+// BalanceOf 获取账户的代币余额(最小单位)
+func (e *ERC20) BalanceOf(ctx context.Context, account common.Address) (*big.Int, error) {
+	out, err := e.call(ctx, "balanceOf", account)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].(*big.Int), nil
+}
+
+// Decimals 获取代币的小数位数
+func (e *ERC20) Decimals(ctx context.Context) (uint8, error) {
+	out, err := e.call(ctx, "decimals")
+	if err != nil {
+		return 0, err
+	}
+	return out[0].(uint8), nil
 }
 
 // GetApproveData 返回approve调用的编码数据
@@ -94,3 +233,20 @@ func (e *ERC20) GetApproveData(spender common.Address, amount *big.Int) ([]byte,
 	// 打包数据
 	return e.abi.Pack("approve", spender, amount)
 }
+
+// call 调用合约的只读方法
+func (e *ERC20) call(ctx context.Context, method string, args ...interface{}) ([]interface{}, error) {
+	data, err := e.abi.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("编码%s调用失败: %w", method, err)
+	}
+	result, err := e.client.CallContract(ctx, ethereum.CallMsg{To: &e.address, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("调用%s失败: %w", method, err)
+	}
+	out, err := e.abi.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("解析%s结果失败: %w", method, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,158 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SwapPhase 跨链交易所处的阶段
+type SwapPhase string
+
+const (
+	PhaseUnknown   SwapPhase = ""
+	PhasePosted    SwapPhase = "POSTED"    // 源链: 已提交
+	PhaseBonded    SwapPhase = "BONDED"    // 源链: LP已绑定
+	PhaseLocked    SwapPhase = "LOCKED"    // 目标链: LP已锁定资金
+	PhaseReleased  SwapPhase = "RELEASED"  // 目标链: 资金已释放给接收方
+	PhaseExecuted  SwapPhase = "EXECUTED"  // 源链: LP已取走源链资金，交易完成
+	PhaseCancelled SwapPhase = "CANCELLED" // 源链: 过期取消，资金已退回
+)
+
+// swapEventPhases 池合约事件名与交易阶段的对应关系
+var swapEventPhases = map[string]SwapPhase{
+	"SwapPosted":    PhasePosted,
+	"SwapBonded":    PhaseBonded,
+	"SwapLocked":    PhaseLocked,
+	"SwapReleased":  PhaseReleased,
+	"SwapExecuted":  PhaseExecuted,
+	"SwapCancelled": PhaseCancelled,
+}
+
+// rank 阶段的先后顺序，用于只向前推进状态
+func (p SwapPhase) rank() int {
+	switch p {
+	case PhasePosted:
+		return 1
+	case PhaseBonded:
+		return 2
+	case PhaseLocked:
+		return 3
+	case PhaseReleased:
+		return 4
+	case PhaseExecuted, PhaseCancelled:
+		return 5
+	}
+	return 0
+}
+
+// Final 是否为终态
+func (p SwapPhase) Final() bool {
+	return p == PhaseExecuted || p == PhaseCancelled
+}
+
+// SwapEvent 池合约上的跨链交易事件
+type SwapEvent struct {
+	Chain       Chain
+	Phase       SwapPhase
+	Encoded     string // 0x开头的encoded
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	LogIndex    uint
+	Removed     bool // 日志因区块重组被移除
+}
+
+// FilterSwapEvents 分段查询区块范围内的所有跨链交易事件
+func (p *MesonPool) FilterSwapEvents(ctx context.Context, chain Chain, fromBlock, toBlock, chunkSize uint64) ([]SwapEvent, error) {
+	return p.filterSwapEvents(ctx, chain, fromBlock, toBlock, chunkSize, [][]common.Hash{p.swapEventTopics()})
+}
+
+// FilterSwapEventsFor 分段查询区块范围内某笔跨链交易的事件
+func (p *MesonPool) FilterSwapEventsFor(ctx context.Context, chain Chain, encodedSwap *big.Int, fromBlock, toBlock, chunkSize uint64) ([]SwapEvent, error) {
+	return p.filterSwapEvents(ctx, chain, fromBlock, toBlock, chunkSize, [][]common.Hash{p.swapEventTopics(), {common.BigToHash(encodedSwap)}})
+}
+
+// filterSwapEvents 查询日志并解析为跨链交易事件
+func (p *MesonPool) filterSwapEvents(ctx context.Context, chain Chain, fromBlock, toBlock, chunkSize uint64, topics [][]common.Hash) ([]SwapEvent, error) {
+	logs, err := p.filterLogs(ctx, fromBlock, toBlock, chunkSize, topics)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]SwapEvent, 0, len(logs))
+	for _, log := range logs {
+		if event, ok := p.parseSwapEvent(chain, log); ok {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// SubscribeSwapEvents 通过websocket订阅跨链交易事件，HTTP连接不支持订阅
+func (p *MesonPool) SubscribeSwapEvents(ctx context.Context, chain Chain, fromBlock uint64, ch chan<- SwapEvent) (ethereum.Subscription, error) {
+	logs := make(chan types.Log)
+	sub, err := p.client.SubscribeFilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(fromBlock),
+		Addresses: []common.Address{p.address},
+		Topics:    [][]common.Hash{p.swapEventTopics()},
+	}, logs)
+	if err != nil {
+		return nil, fmt.Errorf("订阅日志失败: %w", err)
+	}
+
+	go func() {
+		for {
+			select {
+			case log := <-logs:
+				if event, ok := p.parseSwapEvent(chain, log); ok {
+					select {
+					case ch <- event:
+					case <-sub.Err():
+						return
+					}
+				}
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+// swapEventTopics 返回所有跨链交易事件的ID
+func (p *MesonPool) swapEventTopics() []common.Hash {
+	topics := make([]common.Hash, 0, len(swapEventPhases))
+	for name := range swapEventPhases {
+		topics = append(topics, p.abi.Events[name].ID)
+	}
+	return topics
+}
+
+// parseSwapEvent 将日志解析为跨链交易事件
+func (p *MesonPool) parseSwapEvent(chain Chain, log types.Log) (SwapEvent, bool) {
+	if len(log.Topics) < 2 {
+		return SwapEvent{}, false
+	}
+	event, err := p.abi.EventByID(log.Topics[0])
+	if err != nil {
+		return SwapEvent{}, false
+	}
+	phase, ok := swapEventPhases[event.Name]
+	if !ok {
+		return SwapEvent{}, false
+	}
+	return SwapEvent{
+		Chain:       chain,
+		Phase:       phase,
+		Encoded:     log.Topics[1].Hex(),
+		BlockNumber: log.BlockNumber,
+		BlockHash:   log.BlockHash,
+		TxHash:      log.TxHash,
+		LogIndex:    log.Index,
+		Removed:     log.Removed,
+	}, true
+}
@@ -0,0 +1,117 @@
+package meson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrFeeExceeded 中继器编码时给出的手续费超过了允许的范围
+var ErrFeeExceeded = errors.New("手续费超过上限")
+
+// FeeGuard 手续费保护，防止询价和签名之间手续费上涨
+// 各项为零值时不做对应检查
+type FeeGuard struct {
+	MaxFee        decimal.Decimal `json:"maxFee"`          // 最大总手续费(与金额相同单位)
+	MaxFeePercent decimal.Decimal `json:"maxFeePercent"`   // 最大总手续费占金额的百分比，如0.5表示0.5%
+	Quote         *PriceResponse  `json:"quote,omitempty"` // 之前GetPrice的询价结果
+	Tolerance     decimal.Decimal `json:"tolerance"`       // 相对询价允许上涨的百分比
+}
+
+// Check 检查总手续费是否在允许范围内
+func (g *FeeGuard) Check(amount TokenAmount, price *PriceResponse) error {
+	if g == nil {
+		return nil
+	}
+	totalFee, err := decimal.NewFromString(price.TotalFee)
+	if err != nil {
+		return fmt.Errorf("无效的手续费: %s", price.TotalFee)
+	}
+
+	if g.MaxFee.IsPositive() && totalFee.GreaterThan(g.MaxFee) {
+		return fmt.Errorf("%w: 总手续费%s，上限%s", ErrFeeExceeded, totalFee, g.MaxFee)
+	}
+	if g.MaxFeePercent.IsPositive() {
+		limit := amount.Decimal().Mul(g.MaxFeePercent).Div(decimal.NewFromInt(100))
+		if totalFee.GreaterThan(limit) {
+			return fmt.Errorf("%w: 总手续费%s超过金额的%s%%(%s)", ErrFeeExceeded, totalFee, g.MaxFeePercent, limit)
+		}
+	}
+	if g.Quote != nil {
+		quoted, err := decimal.NewFromString(g.Quote.TotalFee)
+		if err != nil {
+			return fmt.Errorf("无效的询价手续费: %s", g.Quote.TotalFee)
+		}
+		limit := quoted.Mul(decimal.NewFromInt(100).Add(g.Tolerance)).Div(decimal.NewFromInt(100))
+		if totalFee.GreaterThan(limit) {
+			return fmt.Errorf("%w: 总手续费%s高于询价%s，超出允许的%s%%", ErrFeeExceeded, totalFee, quoted, g.Tolerance)
+		}
+	}
+	return nil
+}
+
+// PreparedSwap 已由中继器编码、等待签名的跨链交易
+type PreparedSwap struct {
+	FromChain      Chain         `json:"fromChain"`
+	ToChain        Chain         `json:"toChain"`
+	FromToken      Token         `json:"fromToken"`
+	ToToken        Token         `json:"toToken"`
+	Amount         TokenAmount   `json:"amount"`
+	FromAddress    string        `json:"fromAddress"`
+	Recipient      string        `json:"recipient"`
+	Encoded        string        `json:"encoded"`
+	SigningHash    string        `json:"signingHash"`
+	SigningMessage string        `json:"signingMessage"`
+	Price          PriceResponse `json:"price"`
+	ExpireAt       time.Time     `json:"expireAt"`
+
+	// 签名前需要发送的授权交易，由PreviewTransfer或ExportOffline填写
+	ApproveAmount string          `json:"approveAmount,omitempty"` // 授权金额，ApproveMax表示授权最大值，为空表示无需授权
+	EstimatedGas  decimal.Decimal `json:"estimatedGas"`            // 授权交易预计消耗的原生代币
+
+	// Problems 预检发现的问题，由PreviewTransfer填写；不为空时Transfer拒绝执行，除非设置了TransferRequest.Force
+	Problems []PreflightProblem `json:"problems,omitempty"`
+}
+
+// PrepareSwap 由中继器编码跨链交易，并用guard检查编码结果中的手续费
+// 手续费超出范围时返回ErrFeeExceeded，此时交易尚未签名，直接放弃即可
+func (b *Bridge) PrepareSwap(ctx context.Context, req *TransferRequest, guard *FeeGuard) (*PreparedSwap, error) {
+	recipient := req.Recipient
+	if recipient == "" {
+		recipient = req.FromAddress
+	}
+	expireIn := req.ExpireIn
+	if expireIn == 0 {
+		expireIn = DefaultSwapExpiry
+	}
+
+	resp, err := b.encodeSwap(ctx, req.Amount, req.FromAddress, recipient, req.FromChain, req.ToChain, req.FromToken, req.ToToken, expireIn)
+	if err != nil {
+		return nil, err
+	}
+	if err := guard.Check(req.Amount, &resp.PriceInfo); err != nil {
+		return nil, err
+	}
+
+	swap, err := DecodeEncodedSwap(resp.Encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &PreparedSwap{
+		FromChain:      req.FromChain,
+		ToChain:        req.ToChain,
+		FromToken:      req.FromToken,
+		ToToken:        req.ToToken,
+		Amount:         req.Amount,
+		FromAddress:    req.FromAddress,
+		Recipient:      recipient,
+		Encoded:        resp.Encoded,
+		SigningHash:    resp.SigningRequest.Hash,
+		SigningMessage: resp.SigningRequest.Message,
+		Price:          resp.PriceInfo,
+		ExpireAt:       swap.ExpireTime(),
+	}, nil
+}
@@ -0,0 +1,30 @@
+package meson
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeGuard_Check(t *testing.T) {
+	amount := mustTokenAmount(t, "100", MesonDecimals)
+	price := &PriceResponse{TotalFee: "0.6"}
+
+	var guard *FeeGuard
+	require.NoError(t, guard.Check(amount, price))
+
+	guard = &FeeGuard{MaxFee: decimal.RequireFromString("0.5")}
+	require.ErrorIs(t, guard.Check(amount, price), ErrFeeExceeded)
+
+	guard = &FeeGuard{MaxFeePercent: decimal.RequireFromString("1")}
+	require.NoError(t, guard.Check(amount, price))
+	guard.MaxFeePercent = decimal.RequireFromString("0.5")
+	require.ErrorIs(t, guard.Check(amount, price), ErrFeeExceeded)
+
+	// 询价0.5，允许上涨10%即0.55
+	guard = &FeeGuard{Quote: &PriceResponse{TotalFee: "0.5"}, Tolerance: decimal.RequireFromString("10")}
+	require.ErrorIs(t, guard.Check(amount, price), ErrFeeExceeded)
+	guard.Tolerance = decimal.RequireFromString("20")
+	require.NoError(t, guard.Check(amount, price))
+}
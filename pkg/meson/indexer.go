@@ -0,0 +1,229 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// Indexer 池合约事件索引器，跟踪所有已连接链上跨链交易的生命周期
+// 支持websocket的节点通过订阅获取新事件，否则按PollInterval分段轮询
+type Indexer struct {
+	bridge *Bridge
+
+	ChunkSize    uint64           // 分段查询日志时每段的区块数
+	PollInterval time.Duration    // 轮询新区块的间隔
+	StartBlocks  map[Chain]uint64 // 每条链开始扫描的区块，未配置时从当前最新区块开始
+	OnEvent      func(SwapEvent)  // 收到新事件时的回调，在索引器的goroutine中调用
+
+	mu      sync.RWMutex
+	swaps   map[string]*IndexedSwap // key: encoded
+	swapIDs map[string]string       // 中继器swapId到encoded的映射
+}
+
+// IndexedSwap 索引到的跨链交易
+type IndexedSwap struct {
+	Encoded string
+	SwapID  string    // 通过Watch关联的中继器swapId
+	Phase   SwapPhase // 根据事件推进的最新阶段
+	Events  []SwapEvent
+}
+
+// NewIndexer 创建事件索引器，索引bridge中所有已连接且注册了池地址的链
+func NewIndexer(bridge *Bridge) *Indexer {
+	return &Indexer{
+		bridge:       bridge,
+		ChunkSize:    DefaultLogChunkSize,
+		PollInterval: 5 * time.Second,
+		StartBlocks:  make(map[Chain]uint64),
+		swaps:        make(map[string]*IndexedSwap),
+		swapIDs:      make(map[string]string),
+	}
+}
+
+// Watch 将中继器返回的swapId与encoded关联，之后可以通过swapId查询状态
+func (ix *Indexer) Watch(swapId, encoded string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	encoded = strings.ToLower(encoded)
+	ix.swapIDs[swapId] = encoded
+	ix.swap(encoded).SwapID = swapId
+}
+
+// Status 按swapId或encoded查询索引到的跨链交易
+func (ix *Indexer) Status(ref string) (*IndexedSwap, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	encoded := strings.ToLower(ref)
+	if mapped, ok := ix.swapIDs[ref]; ok {
+		encoded = mapped
+	}
+	swap, ok := ix.swaps[encoded]
+	if !ok {
+		return nil, false
+	}
+	copied := *swap
+	copied.Events = append([]SwapEvent(nil), swap.Events...)
+	return &copied, true
+}
+
+// Run 开始索引所有链，直到ctx结束或某条链出错
+func (ix *Indexer) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(ix.bridge.ethClients))
+	for chain, client := range ix.bridge.ethClients {
+		poolAddr, ok := ix.bridge.poolAddrs[chain]
+		if !ok {
+			continue
+		}
+		pool, err := NewMesonPool(client, poolAddr)
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func(chain Chain, client *ethclient.Client, pool *MesonPool) {
+			defer wg.Done()
+			if err := ix.runChain(ctx, chain, client, pool); err != nil && ctx.Err() == nil {
+				errs <- fmt.Errorf("索引%s链失败: %w", chain, err)
+				cancel()
+			}
+		}(chain, client, pool)
+	}
+	wg.Wait()
+	close(errs)
+
+	if err, ok := <-errs; ok {
+		return err
+	}
+	return ctx.Err()
+}
+
+// runChain 索引单条链，优先使用订阅，不支持时退回轮询
+func (ix *Indexer) runChain(ctx context.Context, chain Chain, client *ethclient.Client, pool *MesonPool) error {
+	cursor, ok := ix.StartBlocks[chain]
+	if !ok {
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("获取最新区块失败: %w", err)
+		}
+		cursor = head
+	}
+
+	// 先订阅再补齐历史事件，避免两者之间遗漏，重复事件在apply中去重
+	events := make(chan SwapEvent, 64)
+	sub, err := pool.SubscribeSwapEvents(ctx, chain, cursor, events)
+	if err != nil {
+		return ix.poll(ctx, chain, client, pool, cursor)
+	}
+	defer sub.Unsubscribe()
+
+	head, err := client.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("获取最新区块失败: %w", err)
+	}
+	if cursor, err = ix.scan(ctx, chain, pool, cursor, head); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-events:
+			ix.apply(event)
+			if event.BlockNumber >= cursor {
+				cursor = event.BlockNumber + 1
+			}
+		case err := <-sub.Err():
+			// 订阅断开后从最后处理的区块继续轮询
+			ix.bridge.logf("%s链日志订阅断开: %v，改为轮询\n", chain, err)
+			return ix.poll(ctx, chain, client, pool, cursor)
+		}
+	}
+}
+
+// poll 按PollInterval轮询新区块中的事件
+func (ix *Indexer) poll(ctx context.Context, chain Chain, client *ethclient.Client, pool *MesonPool, cursor uint64) error {
+	for {
+		head, err := client.BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("获取最新区块失败: %w", err)
+		}
+		if cursor, err = ix.scan(ctx, chain, pool, cursor, head); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(ix.PollInterval):
+		}
+	}
+}
+
+// scan 处理[from, to]区间的事件，返回下一个待扫描的区块
+func (ix *Indexer) scan(ctx context.Context, chain Chain, pool *MesonPool, from, to uint64) (uint64, error) {
+	if from > to {
+		return from, nil
+	}
+	events, err := pool.FilterSwapEvents(ctx, chain, from, to, ix.ChunkSize)
+	if err != nil {
+		return from, err
+	}
+	for _, event := range events {
+		ix.apply(event)
+	}
+	return to + 1, nil
+}
+
+// apply 将事件合并到对应的跨链交易，重复事件忽略，被重组移除的事件撤销
+func (ix *Indexer) apply(event SwapEvent) {
+	ix.mu.Lock()
+	swap := ix.swap(strings.ToLower(event.Encoded))
+
+	index := -1
+	for i, existing := range swap.Events {
+		if existing.TxHash == event.TxHash && existing.LogIndex == event.LogIndex {
+			index = i
+			break
+		}
+	}
+	switch {
+	case event.Removed && index >= 0:
+		swap.Events = append(swap.Events[:index], swap.Events[index+1:]...)
+	case event.Removed || index >= 0:
+		ix.mu.Unlock()
+		return
+	default:
+		swap.Events = append(swap.Events, event)
+	}
+
+	swap.Phase = PhaseUnknown
+	for _, existing := range swap.Events {
+		if existing.Phase.rank() > swap.Phase.rank() {
+			swap.Phase = existing.Phase
+		}
+	}
+	ix.mu.Unlock()
+
+	if ix.OnEvent != nil {
+		ix.OnEvent(event)
+	}
+}
+
+// swap 获取或创建跨链交易记录，调用方需持有锁
+func (ix *Indexer) swap(encoded string) *IndexedSwap {
+	swap, ok := ix.swaps[encoded]
+	if !ok {
+		swap = &IndexedSwap{Encoded: encoded}
+		ix.swaps[encoded] = swap
+	}
+	return swap
+}
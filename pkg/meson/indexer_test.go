@@ -0,0 +1,41 @@
+package meson
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndexer_Apply(t *testing.T) {
+	ix := NewIndexer(NewBridge())
+	encoded := "0x0100005B8D80C00000000000E75526200000000000006592116A02CA450A8845"
+	ix.Watch("swap-1", encoded)
+
+	var received []SwapPhase
+	ix.OnEvent = func(event SwapEvent) { received = append(received, event.Phase) }
+
+	posted := SwapEvent{Chain: ChainMerlin, Phase: PhasePosted, Encoded: encoded, TxHash: common.HexToHash("0x1")}
+	locked := SwapEvent{Chain: "bnb", Phase: PhaseLocked, Encoded: encoded, TxHash: common.HexToHash("0x2")}
+	bonded := SwapEvent{Chain: ChainMerlin, Phase: PhaseBonded, Encoded: encoded, TxHash: common.HexToHash("0x3")}
+
+	// 跨链事件可能乱序到达，阶段只向前推进
+	ix.apply(posted)
+	ix.apply(locked)
+	ix.apply(bonded)
+	ix.apply(posted)
+
+	swap, ok := ix.Status("swap-1")
+	require.True(t, ok)
+	require.Equal(t, PhaseLocked, swap.Phase)
+	require.Len(t, swap.Events, 3)
+	require.Equal(t, []SwapPhase{PhasePosted, PhaseLocked, PhaseBonded}, received)
+
+	// 锁定事件被重组移除后回退
+	locked.Removed = true
+	ix.apply(locked)
+	swap, ok = ix.Status(encoded)
+	require.True(t, ok)
+	require.Equal(t, PhaseBonded, swap.Phase)
+	require.Equal(t, "swap-1", swap.SwapID)
+}
@@ -0,0 +1,214 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+)
+
+// RouteLeg 路径中的一段跨链
+type RouteLeg struct {
+	From   ChainTokenKey   `json:"from"`
+	To     ChainTokenKey   `json:"to"`
+	Amount TokenAmount     `json:"amount"` // 本段发送的金额
+	Fee    decimal.Decimal `json:"fee"`    // 本段总手续费
+	Price  *PriceResponse  `json:"price"`
+}
+
+// RoutePlan 从源到目标的跨链路径，中继器不支持直达时经过一条中转链
+type RoutePlan struct {
+	From     ChainTokenKey   `json:"from"`
+	To       ChainTokenKey   `json:"to"`
+	Amount   TokenAmount     `json:"amount"`
+	Legs     []*RouteLeg     `json:"legs"`
+	TotalFee decimal.Decimal `json:"totalFee"` // 各段手续费之和
+	Received decimal.Decimal `json:"received"` // 预计到账金额
+}
+
+// Direct 是否为直达路径
+func (p *RoutePlan) Direct() bool {
+	return len(p.Legs) == 1
+}
+
+// RouteLegError 多段跨链中某一段失败，Guidance说明资金所在位置和处理建议
+type RouteLegError struct {
+	Leg      int         // 失败的段，从0开始
+	Record   *SwapRecord // 该段的转账记录，未创建时为nil
+	Guidance string
+	Err      error
+}
+
+func (e *RouteLegError) Error() string {
+	return fmt.Sprintf("第%d段跨链失败: %v。%s", e.Leg+1, e.Err, e.Guidance)
+}
+
+func (e *RouteLegError) Unwrap() error {
+	return e.Err
+}
+
+// routeNodes 返回注册表中可以作为源链或中转链的链和代币: 已注册代币地址且已注册池地址
+func (b *Bridge) routeNodes() []ChainTokenKey {
+	var nodes []ChainTokenKey
+	for key := range b.tokenAddrs {
+		if _, ok := b.poolAddrs[key.Chain]; ok {
+			nodes = append(nodes, key)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Chain != nodes[j].Chain {
+			return nodes[i].Chain < nodes[j].Chain
+		}
+		return nodes[i].Token < nodes[j].Token
+	})
+	return nodes
+}
+
+// PlanRoute 规划跨链路径: 中继器支持直达时返回直达路径，否则在注册表中寻找中转链，
+// 返回两段手续费之和最低的路径。中转链需要注册代币和池地址，以便在该链上发起第二段
+func (b *Bridge) PlanRoute(from, to ChainTokenKey, amount TokenAmount, fromAddr string) (*RoutePlan, error) {
+	leg, directErr := b.quoteLeg(from, to, amount, fromAddr)
+	if directErr == nil {
+		return newRoutePlan(from, to, amount, leg), nil
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		plans []*RoutePlan
+	)
+	for _, mid := range b.routeNodes() {
+		if mid == from || mid == to || mid.Chain == from.Chain || mid.Chain == to.Chain {
+			continue
+		}
+		wg.Add(1)
+		go func(mid ChainTokenKey) {
+			defer wg.Done()
+			first, err := b.quoteLeg(from, mid, amount, fromAddr)
+			if err != nil {
+				return
+			}
+			received, err := NewTokenAmount(amount.Decimal().Sub(first.Fee), MesonDecimals)
+			if err != nil || received.IsZero() {
+				return
+			}
+			second, err := b.quoteLeg(mid, to, received, fromAddr)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			plans = append(plans, newRoutePlan(from, to, amount, first, second))
+			mu.Unlock()
+		}(mid)
+	}
+	wg.Wait()
+
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("没有可用的跨链路径: %s:%s -> %s:%s，直达失败: %w", from.Chain, from.Token, to.Chain, to.Token, directErr)
+	}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].TotalFee.LessThan(plans[j].TotalFee)
+	})
+	return plans[0], nil
+}
+
+// quoteLeg 查询一段跨链的手续费，到账金额不大于0时视为不可用
+func (b *Bridge) quoteLeg(from, to ChainTokenKey, amount TokenAmount, fromAddr string) (*RouteLeg, error) {
+	price, err := b.GetPrice(amount, fromAddr, from.Chain, to.Chain, from.Token, to.Token)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := decimal.NewFromString(price.TotalFee)
+	if err != nil {
+		return nil, fmt.Errorf("无效的手续费: %s", price.TotalFee)
+	}
+	if fee.GreaterThanOrEqual(amount.Decimal()) {
+		return nil, fmt.Errorf("金额%s不足以支付手续费%s", amount, fee)
+	}
+	return &RouteLeg{From: from, To: to, Amount: amount, Fee: fee, Price: price}, nil
+}
+
+func newRoutePlan(from, to ChainTokenKey, amount TokenAmount, legs ...*RouteLeg) *RoutePlan {
+	plan := &RoutePlan{From: from, To: to, Amount: amount, Legs: legs}
+	for _, leg := range legs {
+		plan.TotalFee = plan.TotalFee.Add(leg.Fee)
+	}
+	plan.Received = amount.Decimal().Sub(plan.TotalFee)
+	return plan
+}
+
+// ExecuteRoute 按顺序执行路径中的各段跨链，前一段在目标链释放后才发起下一段
+// senders按链提供各段源链的交易发送器，中间段的接收地址为下一段发送器的地址
+// 下一段的金额按前一段实际扣除的手续费计算。某一段失败时返回*RouteLegError
+func (b *Bridge) ExecuteRoute(ctx context.Context, plan *RoutePlan, senders map[Chain]*helpers.Sender, recipient string) ([]*SwapRecord, error) {
+	for _, leg := range plan.Legs {
+		if senders[leg.From.Chain] == nil {
+			return nil, fmt.Errorf("缺少%s链的交易发送器", leg.From.Chain)
+		}
+	}
+
+	var records []*SwapRecord
+	amount := plan.Amount
+	for i, leg := range plan.Legs {
+		sender := senders[leg.From.Chain]
+		legRecipient := recipient
+		if i < len(plan.Legs)-1 {
+			legRecipient = senders[plan.Legs[i+1].From.Chain].From().Hex()
+		}
+
+		b.logf("执行第%d段跨链: %s:%s -> %s:%s，金额%s\n", i+1, leg.From.Chain, leg.From.Token, leg.To.Chain, leg.To.Token, amount)
+		record, err := b.Transfer(ctx, sender, &TransferRequest{
+			FromChain: leg.From.Chain,
+			ToChain:   leg.To.Chain,
+			FromToken: leg.From.Token,
+			ToToken:   leg.To.Token,
+			Amount:    amount,
+			Recipient: legRecipient,
+		})
+		if err == nil {
+			record, err = b.WatchTransfer(ctx, sender, record.ID)
+		}
+		if record != nil {
+			records = append(records, record)
+		}
+		if err == nil && record.Status != StatusCompleted {
+			err = fmt.Errorf("转账结束状态为%s", record.Status)
+		}
+		if err != nil {
+			return records, &RouteLegError{Leg: i, Record: record, Guidance: legGuidance(plan, i, record), Err: err}
+		}
+
+		if i == len(plan.Legs)-1 {
+			break
+		}
+		// 下一段发送本段实际到账的金额
+		fee, err := decimal.NewFromString(record.Fee)
+		if err != nil {
+			fee = leg.Fee
+		}
+		amount, err = NewTokenAmount(amount.Decimal().Sub(fee), MesonDecimals)
+		if err != nil {
+			return records, &RouteLegError{Leg: i + 1, Guidance: legGuidance(plan, i+1, nil), Err: err}
+		}
+	}
+	return records, nil
+}
+
+// legGuidance 根据失败的段给出资金位置和处理建议
+func legGuidance(plan *RoutePlan, leg int, record *SwapRecord) string {
+	current := plan.Legs[leg].From
+	switch {
+	case record != nil && record.Status == StatusSubmitted:
+		return fmt.Sprintf("该段已提交但未完成，可稍后通过WatchTransfer(%s)继续等待，过期后会自动取消退款到%s链", record.ID, current.Chain)
+	case record != nil && record.Status == StatusCancelled:
+		return fmt.Sprintf("该段已取消，资金已退回%s链，可重新规划路径", current.Chain)
+	case leg == 0:
+		return fmt.Sprintf("资金仍在源链%s上，可直接重试", current.Chain)
+	default:
+		return fmt.Sprintf("资金已到达中转链%s(代币%s)，可在该链上重新发起剩余路径，或跨回源链%s", current.Chain, current.Token, plan.From.Chain)
+	}
+}
@@ -0,0 +1,38 @@
+package meson
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteNodes(t *testing.T) {
+	bridge := NewBridge()
+	bridge.tokenAddrs[ChainTokenKey{Chain: ChainMerlin, Token: TokenMERL}] = common.HexToAddress(MERLAddress)
+	bridge.tokenAddrs[ChainTokenKey{Chain: "bnb", Token: TokenMERL}] = common.HexToAddress(MERLAddress)
+	bridge.poolAddrs[ChainMerlin] = common.HexToAddress(PoolAddress)
+
+	// bnb链未注册池地址，不能作为中转链
+	require.Equal(t, []ChainTokenKey{{Chain: ChainMerlin, Token: TokenMERL}}, bridge.routeNodes())
+}
+
+func TestRoutePlan(t *testing.T) {
+	from := ChainTokenKey{Chain: ChainMerlin, Token: TokenMBTC}
+	mid := ChainTokenKey{Chain: "bnb", Token: TokenMBTC}
+	to := ChainTokenKey{Chain: ChainZksync, Token: TokenMBTC}
+	amount := mustTokenAmount(t, "1", MesonDecimals)
+
+	plan := newRoutePlan(from, to, amount,
+		&RouteLeg{From: from, To: mid, Amount: amount, Fee: decimal.RequireFromString("0.001")},
+		&RouteLeg{From: mid, To: to, Amount: mustTokenAmount(t, "0.999", MesonDecimals), Fee: decimal.RequireFromString("0.002")},
+	)
+	require.False(t, plan.Direct())
+	require.Equal(t, "0.003", plan.TotalFee.String())
+	require.Equal(t, "0.997", plan.Received.String())
+
+	require.Contains(t, legGuidance(plan, 0, nil), "源链")
+	require.Contains(t, legGuidance(plan, 1, nil), "中转链bnb")
+	require.Contains(t, legGuidance(plan, 1, &SwapRecord{ID: "x", Status: StatusSubmitted}), "WatchTransfer")
+}
@@ -0,0 +1,496 @@
+package meson
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+)
+
+// OfflineVersion 离线签名文件的格式版本
+const OfflineVersion = 1
+
+// offlineReceiptInterval 提交离线签名的approve交易后查询收据的间隔
+var offlineReceiptInterval = 2 * time.Second
+
+// UnsignedTx 待离线签名的交易，签名时按EIP-155生成legacy交易
+type UnsignedTx struct {
+	ChainID  string `json:"chainId"`
+	Nonce    uint64 `json:"nonce"`
+	To       string `json:"to"`
+	Data     string `json:"data"`
+	Value    string `json:"value"`
+	Gas      uint64 `json:"gas"`
+	GasPrice string `json:"gasPrice"`
+}
+
+// transaction 生成未签名的交易
+func (u *UnsignedTx) transaction() (*types.Transaction, *big.Int, error) {
+	chainID, ok := new(big.Int).SetString(u.ChainID, 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("无效的链ID: %s", u.ChainID)
+	}
+	value, ok := new(big.Int).SetString(u.Value, 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("无效的交易金额: %s", u.Value)
+	}
+	gasPrice, ok := new(big.Int).SetString(u.GasPrice, 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("无效的gas价格: %s", u.GasPrice)
+	}
+	if !common.IsHexAddress(u.To) {
+		return nil, nil, fmt.Errorf("无效的交易目标地址: %s", u.To)
+	}
+	data, err := hexutil.Decode(u.Data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无效的交易数据: %w", err)
+	}
+	to := common.HexToAddress(u.To)
+	return types.NewTx(&types.LegacyTx{
+		Nonce:    u.Nonce,
+		To:       &to,
+		Value:    value,
+		Gas:      u.Gas,
+		GasPrice: gasPrice,
+		Data:     data,
+	}), chainID, nil
+}
+
+// OfflineRequest 离线签名文件
+// 由联网主机通过ExportOffline导出，在离线主机上用SignOffline签名，再由联网主机通过SubmitOffline提交
+type OfflineRequest struct {
+	Version     int           `json:"version"`
+	FromAddress string        `json:"fromAddress"`
+	Approve     *UnsignedTx   `json:"approve,omitempty"` // 授权额度不足时需要签名的approve交易
+	Swap        *PreparedSwap `json:"swap"`              // 需要签名的Meson跨链交易
+	CreatedAt   time.Time     `json:"createdAt"`
+
+	// 以下字段由SignOffline填写
+	SignedApprove string `json:"signedApprove,omitempty"` // 已签名approve交易的RLP编码
+	Signature     string `json:"signature,omitempty"`     // 对Swap.SigningHash的签名
+}
+
+// Signed 是否已完成签名
+func (r *OfflineRequest) Signed() bool {
+	return r.Signature != "" && (r.Approve == nil || r.SignedApprove != "")
+}
+
+// WriteFile 将离线签名文件写入path
+func (r *OfflineRequest) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化离线签名文件失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("写入离线签名文件失败: %w", err)
+	}
+	return nil
+}
+
+// ReadOfflineRequest 读取离线签名文件
+func ReadOfflineRequest(path string) (*OfflineRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取离线签名文件失败: %w", err)
+	}
+	r := &OfflineRequest{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, fmt.Errorf("解析离线签名文件失败: %w", err)
+	}
+	if r.Version != OfflineVersion {
+		return nil, fmt.Errorf("不支持的离线签名文件版本: %d", r.Version)
+	}
+	if r.Swap == nil {
+		return nil, errors.New("离线签名文件缺少跨链交易")
+	}
+	return r, nil
+}
+
+// ExportOffline 为离线签名准备转账: 授权额度不足且未设置SkipApprove时生成只授权转账金额的approve交易，
+// 并由中继器编码跨链交易，guard用于检查手续费
+// 只需要发送地址，不需要私钥；approve交易的nonce和gas价格在导出时确定，签名后应尽快提交
+func (b *Bridge) ExportOffline(ctx context.Context, req *TransferRequest, guard *FeeGuard) (*OfflineRequest, error) {
+	if !common.IsHexAddress(req.FromAddress) {
+		return nil, fmt.Errorf("无效的发送地址: %s", req.FromAddress)
+	}
+	copied := *req
+	req = &copied
+	if req.FromChain == "" {
+		req.FromChain = b.currentChain
+	}
+	from := common.HexToAddress(req.FromAddress)
+
+	r := &OfflineRequest{
+		Version:     OfflineVersion,
+		FromAddress: from.Hex(),
+		CreatedAt:   time.Now(),
+	}
+	if !req.SkipApprove {
+		allowance, err := b.GetAllowance(ctx, req.FromChain, req.FromToken, r.FromAddress)
+		if err != nil {
+			return nil, err
+		}
+		if allowance.Decimal().LessThan(req.Amount.Decimal()) {
+			if r.Approve, err = b.unsignedApprove(ctx, req, from); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	prepared, err := b.PrepareSwap(ctx, req, guard)
+	if err != nil {
+		return nil, err
+	}
+	if r.Approve != nil {
+		prepared.ApproveAmount = req.Amount.String()
+		gasPrice, _ := new(big.Int).SetString(r.Approve.GasPrice, 10)
+		cost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(r.Approve.Gas))
+		prepared.EstimatedGas = decimal.NewFromBigInt(cost, -18)
+	}
+	r.Swap = prepared
+	return r, nil
+}
+
+// unsignedApprove 生成待签名的approve交易
+func (b *Bridge) unsignedApprove(ctx context.Context, req *TransferRequest, from common.Address) (*UnsignedTx, error) {
+	txData, err := b.GetApproveAmountData(ctx, req.FromChain, req.FromToken, req.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("获取Approve数据失败: %w", err)
+	}
+	client, err := b.clientFor(req.FromChain)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取链ID失败: %w", err)
+	}
+	nonce, err := client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, fmt.Errorf("获取nonce失败: %w", err)
+	}
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取gas价格失败: %w", err)
+	}
+	gas, err := helpers.EstimateGas(ctx, client, from, txData)
+	if err != nil {
+		return nil, fmt.Errorf("估算Approve交易gas失败: %w", err)
+	}
+	return &UnsignedTx{
+		ChainID:  chainID.String(),
+		Nonce:    nonce,
+		To:       txData.To.Hex(),
+		Data:     hexutil.Encode(txData.Data),
+		Value:    "0",
+		Gas:      gas,
+		GasPrice: gasPrice.String(),
+	}, nil
+}
+
+// ApproveCall 解码后的approve交易
+type ApproveCall struct {
+	Token   common.Address // 代币合约
+	Spender common.Address // 被授权地址，应为源链池合约
+	Amount  *big.Int       // 授权额度(代币最小单位)
+}
+
+// VerifyOffline 在离线主机上核对离线签名文件，签名前应调用并以核对后的内容向用户确认:
+// 跨链交易的金额、过期时间和链由encoded解码后与文件中展示的字段比对，签名哈希由encoded重新计算，
+// approve交易解码后确认被授权地址为pool。返回解码后的approve交易，没有approve交易时为nil
+func VerifyOffline(r *OfflineRequest, pool common.Address) (*ApproveCall, error) {
+	swap := r.Swap
+	if err := swap.verifyEncoded(); err != nil {
+		return nil, err
+	}
+	if !sameAddress(swap.FromAddress, common.HexToAddress(r.FromAddress)) {
+		return nil, fmt.Errorf("跨链交易的发送地址%s与文件的发送地址%s不一致", swap.FromAddress, r.FromAddress)
+	}
+
+	if r.Approve == nil {
+		return nil, nil
+	}
+	call, err := decodeApprove(r.Approve)
+	if err != nil {
+		return nil, err
+	}
+	if call.Spender != pool {
+		return nil, fmt.Errorf("approve交易授权给%s，不是池合约%s", call.Spender.Hex(), pool.Hex())
+	}
+	return call, nil
+}
+
+// verifyEncoded 由encoded解码金额、过期时间和链，与展示给用户的字段比对，并由encoded重新计算签名哈希
+func (p *PreparedSwap) verifyEncoded() error {
+	encoded, err := DecodeEncodedSwap(p.Encoded)
+	if err != nil {
+		return err
+	}
+	amount, err := p.Amount.MesonAmount()
+	if err != nil {
+		return err
+	}
+	if encoded.Amount.Cmp(amount.BaseUnits()) != 0 {
+		return fmt.Errorf("跨链金额与encoded不一致: 展示为%s，encoded中为%s", p.Amount, TokenAmountFromBase(encoded.Amount, MesonDecimals))
+	}
+	if encoded.ExpireTs != p.ExpireAt.Unix() {
+		return fmt.Errorf("过期时间与encoded不一致: 展示为%s，encoded中为%s",
+			p.ExpireAt.Format(time.DateTime), time.Unix(encoded.ExpireTs, 0).Format(time.DateTime))
+	}
+	if chain, ok := ChainCodeMap[encoded.InChain]; ok && chain != p.FromChain {
+		return fmt.Errorf("源链与encoded不一致: 展示为%s，encoded中为%s", p.FromChain, chain)
+	}
+	if chain, ok := ChainCodeMap[encoded.OutChain]; ok && chain != p.ToChain {
+		return fmt.Errorf("目标链与encoded不一致: 展示为%s，encoded中为%s", p.ToChain, chain)
+	}
+	if hash := encoded.RequestHash(); common.HexToHash(p.SigningHash) != hash {
+		return fmt.Errorf("签名哈希与encoded不一致: 展示为%s，应为%s", p.SigningHash, hash.Hex())
+	}
+	return nil
+}
+
+// decodeApprove 解码approve交易的调用数据
+func decodeApprove(u *UnsignedTx) (*ApproveCall, error) {
+	data, err := hexutil.Decode(u.Data)
+	if err != nil {
+		return nil, fmt.Errorf("无效的交易数据: %w", err)
+	}
+	parsed, err := abi.JSON(strings.NewReader(erc20ABI))
+	if err != nil {
+		return nil, fmt.Errorf("解析ABI失败: %w", err)
+	}
+	method := parsed.Methods["approve"]
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return nil, errors.New("待签名的交易不是approve调用")
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("解码approve参数失败: %w", err)
+	}
+	if u.Value != "0" {
+		return nil, fmt.Errorf("approve交易不应转账原生代币: %s", u.Value)
+	}
+	if !common.IsHexAddress(u.To) {
+		return nil, fmt.Errorf("无效的交易目标地址: %s", u.To)
+	}
+	return &ApproveCall{
+		Token:   common.HexToAddress(u.To),
+		Spender: args[0].(common.Address),
+		Amount:  args[1].(*big.Int),
+	}, nil
+}
+
+// SignOffline 用私钥签名离线签名文件中的approve交易和跨链交易，不需要连接节点或中继器
+// 私钥对应的地址必须与文件中的发送地址一致，签名前先通过VerifyOffline核对文件，pool为源链池合约地址
+func SignOffline(r *OfflineRequest, privateKey *ecdsa.PrivateKey, pool common.Address) error {
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	if !sameAddress(r.FromAddress, from) {
+		return fmt.Errorf("私钥地址%s与发送地址%s不一致", from.Hex(), r.FromAddress)
+	}
+	if time.Now().After(r.Swap.ExpireAt) {
+		return fmt.Errorf("跨链交易已于%s过期，请重新导出", r.Swap.ExpireAt.Format(time.DateTime))
+	}
+	if _, err := VerifyOffline(r, pool); err != nil {
+		return err
+	}
+
+	if r.Approve != nil {
+		tx, chainID, err := r.Approve.transaction()
+		if err != nil {
+			return err
+		}
+		signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+		if err != nil {
+			return fmt.Errorf("签名Approve交易失败: %w", err)
+		}
+		raw, err := signedTx.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("编码Approve交易失败: %w", err)
+		}
+		r.SignedApprove = hexutil.Encode(raw)
+	}
+
+	signature, err := helpers.SignHash(common.FromHex(r.Swap.SigningHash), privateKey)
+	if err != nil {
+		return fmt.Errorf("签名失败: %w", err)
+	}
+	r.Signature = hexutil.Encode(signature)
+	return nil
+}
+
+// SubmitOffline 提交已离线签名的转账: 先广播approve交易并等待打包，再将跨链交易提交到中继器
+// 广播前先保存转账记录，之后可以用WatchTransfer或Resume跟踪；同一文件重复提交时使用同一条记录，
+// 已打包的approve交易会被跳过，已提交的跨链交易直接返回记录
+func (b *Bridge) SubmitOffline(ctx context.Context, r *OfflineRequest) (*SwapRecord, error) {
+	if !r.Signed() {
+		return nil, errors.New("离线签名文件尚未签名")
+	}
+	swap := r.Swap
+	id := recordIDForKey("offline:" + swap.SigningHash)
+	record, err := b.store.Get(id)
+	switch {
+	case err == nil && (record.Status == StatusSubmitted || record.Status.Final()):
+		return record, nil
+	case errors.Is(err, ErrRecordNotFound):
+		record = &SwapRecord{
+			ID:          id,
+			Status:      StatusSigned,
+			FromChain:   swap.FromChain,
+			ToChain:     swap.ToChain,
+			FromToken:   swap.FromToken,
+			ToToken:     swap.ToToken,
+			Amount:      swap.Amount.String(),
+			FromAddress: r.FromAddress,
+			Recipient:   swap.Recipient,
+			SkipApprove: r.Approve == nil,
+			Encoded:     swap.Encoded,
+			SigningHash: swap.SigningHash,
+			Fee:         swap.Price.TotalFee,
+			Signature:   r.Signature,
+			CreatedAt:   time.Now(),
+		}
+	case err != nil:
+		return nil, fmt.Errorf("读取转账记录失败: %w", err)
+	}
+	if time.Now().After(swap.ExpireAt) {
+		return record, b.finish(record, StatusExpired, fmt.Errorf("跨链交易已于%s过期，请重新导出", swap.ExpireAt.Format(time.DateTime)))
+	}
+	record.UpdatedAt = time.Now()
+	if err := b.store.Save(record); err != nil {
+		return nil, fmt.Errorf("保存转账记录失败: %w", err)
+	}
+
+	if r.Approve != nil {
+		hash, err := b.broadcastSigned(ctx, swap.FromChain, r)
+		if hash != (common.Hash{}) {
+			record.ApproveTxHash = hash.Hex()
+		}
+		if err != nil {
+			return record, b.recordError(record, err)
+		}
+	}
+
+	swapId, err := b.SubmitSwap(swap.Encoded, r.FromAddress, swap.Recipient, common.FromHex(r.Signature))
+	if err != nil {
+		return record, b.recordError(record, err)
+	}
+	record.SwapID = swapId
+	record.Status = StatusSubmitted
+	record.Error = ""
+	record.UpdatedAt = time.Now()
+	if err := b.store.Save(record); err != nil {
+		return record, fmt.Errorf("保存转账记录失败: %w", err)
+	}
+	return record, nil
+}
+
+// recordError 在记录中保存失败原因，记录保持当前状态以便重试
+func (b *Bridge) recordError(record *SwapRecord, cause error) error {
+	record.Error = cause.Error()
+	record.UpdatedAt = time.Now()
+	if err := b.store.Save(record); err != nil {
+		return fmt.Errorf("保存转账记录失败: %w", err)
+	}
+	return cause
+}
+
+// checkSignedTx 检查已签名的交易与待签名的交易各字段一致且由from签名，任一字段不一致时拒绝
+func checkSignedTx(signedTx, unsigned *types.Transaction, chainID *big.Int, from string) error {
+	signer, err := types.Sender(types.NewEIP155Signer(chainID), signedTx)
+	if err != nil {
+		return fmt.Errorf("校验交易签名失败: %w", err)
+	}
+	var mismatch string
+	switch {
+	case !sameAddress(from, signer):
+		mismatch = "签名地址"
+	case signedTx.ChainId().Cmp(chainID) != 0:
+		mismatch = "链ID"
+	case signedTx.Nonce() != unsigned.Nonce():
+		mismatch = "nonce"
+	case signedTx.To() == nil || *signedTx.To() != *unsigned.To():
+		mismatch = "接收地址"
+	case !bytes.Equal(signedTx.Data(), unsigned.Data()):
+		mismatch = "调用数据"
+	case signedTx.Value().Cmp(unsigned.Value()) != 0:
+		mismatch = "转账金额"
+	case signedTx.Gas() != unsigned.Gas():
+		mismatch = "gas上限"
+	case signedTx.GasPrice().Cmp(unsigned.GasPrice()) != 0:
+		mismatch = "gas价格"
+	default:
+		return nil
+	}
+	return fmt.Errorf("已签名的交易与待签名的Approve交易不一致: %s不同", mismatch)
+}
+
+// broadcastSigned 广播已签名的approve交易并等待打包
+// 交易必须与文件中待签名的交易一致，且由发送地址签名
+func (b *Bridge) broadcastSigned(ctx context.Context, chain Chain, r *OfflineRequest) (common.Hash, error) {
+	raw, err := hexutil.Decode(r.SignedApprove)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("无效的已签名交易: %w", err)
+	}
+	signedTx := new(types.Transaction)
+	if err := signedTx.UnmarshalBinary(raw); err != nil {
+		return common.Hash{}, fmt.Errorf("解析已签名交易失败: %w", err)
+	}
+	unsigned, chainID, err := r.Approve.transaction()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := checkSignedTx(signedTx, unsigned, chainID, r.FromAddress); err != nil {
+		return common.Hash{}, err
+	}
+
+	client, err := b.clientFor(chain)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash := signedTx.Hash()
+	_, _, err = client.TransactionByHash(ctx, hash)
+	switch {
+	case errors.Is(err, ethereum.NotFound):
+		if err := client.SendTransaction(ctx, signedTx); err != nil {
+			return hash, fmt.Errorf("发送Approve交易失败: %w", err)
+		}
+		b.logf("Approve交易已发送! 哈希: %s\n", hash.Hex())
+	case err != nil:
+		return hash, fmt.Errorf("查询Approve交易失败: %w", err)
+	}
+
+	for {
+		receipt, err := client.TransactionReceipt(ctx, hash)
+		if err == nil {
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				return hash, &helpers.TxFailedError{TxHash: hash, BlockNumber: receipt.BlockNumber, BlockHash: receipt.BlockHash, GasUsed: receipt.GasUsed}
+			}
+			b.logf("Approve交易已确认! 区块高度: %d\n", receipt.BlockNumber)
+			return hash, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return hash, fmt.Errorf("查询交易收据失败: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return hash, ctx.Err()
+		case <-time.After(offlineReceiptInterval):
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package meson
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// testSwapEncoded 按字段拼接encoded: 6 MERL从merlin到zksync
+func testSwapEncoded(salt string, expireAt time.Time) string {
+	return fmt.Sprintf("0x01%010x%s%010x%010x%04x%02x%04x%02x", 6000000, salt, 0, expireAt.Unix(), 0x0324, 69, 0x1068, 69)
+}
+
+func newOfflineRequest(t *testing.T, from string) *OfflineRequest {
+	expireAt := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+	encoded := testSwapEncoded("c00000000000e7552620", expireAt)
+	swap, err := DecodeEncodedSwap(encoded)
+	require.NoError(t, err)
+
+	erc20, err := NewERC20(nil, common.HexToAddress(MERLAddress))
+	require.NoError(t, err)
+	approve, err := erc20.GetApproveData(common.HexToAddress(PoolAddress), big.NewInt(6e18))
+	require.NoError(t, err)
+
+	return &OfflineRequest{
+		Version:     OfflineVersion,
+		FromAddress: from,
+		Approve: &UnsignedTx{
+			ChainID:  "4200",
+			Nonce:    7,
+			To:       MERLAddress,
+			Data:     hexutil.Encode(approve),
+			Value:    "0",
+			Gas:      60000,
+			GasPrice: "50000000",
+		},
+		Swap: &PreparedSwap{
+			FromChain:   ChainMerlin,
+			ToChain:     ChainZksync,
+			Amount:      mustTokenAmount(t, "6", MesonDecimals),
+			FromAddress: from,
+			Recipient:   from,
+			Encoded:     encoded,
+			SigningHash: swap.RequestHash().Hex(),
+			ExpireAt:    expireAt,
+		},
+	}
+}
+
+func TestSignOffline(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	pool := common.HexToAddress(PoolAddress)
+
+	r := newOfflineRequest(t, from.Hex())
+	require.False(t, r.Signed())
+
+	// 文件经过写入和读取后再签名
+	path := filepath.Join(t.TempDir(), "offline.json")
+	require.NoError(t, r.WriteFile(path))
+	r, err = ReadOfflineRequest(path)
+	require.NoError(t, err)
+	require.NoError(t, SignOffline(r, key, pool))
+	require.True(t, r.Signed())
+
+	// 跨链交易签名的v值为27/28，恢复出的地址为发送地址
+	signature := hexutil.MustDecode(r.Signature)
+	require.Contains(t, []byte{27, 28}, signature[64])
+	signature[64] -= 27
+	pub, err := crypto.SigToPub(hexutil.MustDecode(r.Swap.SigningHash), signature)
+	require.NoError(t, err)
+	require.Equal(t, from, crypto.PubkeyToAddress(*pub))
+
+	// approve交易按EIP-155签名
+	signedTx := new(types.Transaction)
+	require.NoError(t, signedTx.UnmarshalBinary(hexutil.MustDecode(r.SignedApprove)))
+	require.EqualValues(t, 7, signedTx.Nonce())
+	signer, err := types.Sender(types.NewEIP155Signer(signedTx.ChainId()), signedTx)
+	require.NoError(t, err)
+	require.Equal(t, from, signer)
+
+	// 其他私钥不能签名
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	require.Error(t, SignOffline(newOfflineRequest(t, from.Hex()), other, pool))
+
+	// 过期的交易不能签名
+	expired := newOfflineRequest(t, from.Hex())
+	expired.Swap.ExpireAt = time.Now().Add(-time.Minute)
+	require.Error(t, SignOffline(expired, key, pool))
+}
+
+func TestVerifyOffline(t *testing.T) {
+	from := "0x2F913C820ed3bEb3a67391a6eFF64E70c4B20b19"
+	pool := common.HexToAddress(PoolAddress)
+
+	call, err := VerifyOffline(newOfflineRequest(t, from), pool)
+	require.NoError(t, err)
+	require.Equal(t, pool, call.Spender)
+	require.Equal(t, common.HexToAddress(MERLAddress), call.Token)
+	require.Equal(t, big.NewInt(6e18), call.Amount)
+
+	tests := []struct {
+		name   string
+		tamper func(r *OfflineRequest)
+		errMsg string
+	}{
+		{"amount", func(r *OfflineRequest) { r.Swap.Amount = mustTokenAmount(t, "60", MesonDecimals) }, "跨链金额"},
+		{"expiry", func(r *OfflineRequest) { r.Swap.ExpireAt = r.Swap.ExpireAt.Add(time.Hour) }, "过期时间"},
+		{"chain", func(r *OfflineRequest) { r.Swap.ToChain = "bnb" }, "目标链"},
+		{"signing hash", func(r *OfflineRequest) { r.Swap.SigningHash = hexutil.Encode(crypto.Keccak256([]byte("other"))) }, "签名哈希"},
+		{"encoded", func(r *OfflineRequest) {
+			r.Swap.Encoded = testSwapEncoded("c00000000000e7552621", r.Swap.ExpireAt)
+		}, "签名哈希"},
+		{"spender", func(r *OfflineRequest) {
+			erc20, _ := NewERC20(nil, common.HexToAddress(MERLAddress))
+			data, _ := erc20.GetApproveData(common.HexToAddress(from), big.NewInt(6e18))
+			r.Approve.Data = hexutil.Encode(data)
+		}, "不是池合约"},
+		{"not approve", func(r *OfflineRequest) { r.Approve.Data = "0xa9059cbb" }, "不是approve"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newOfflineRequest(t, from)
+			tt.tamper(r)
+			_, err := VerifyOffline(r, pool)
+			require.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestRequestHash(t *testing.T) {
+	expireAt := time.Unix(0x6592116a, 0)
+	swap, err := DecodeEncodedSwap(testSwapEncoded("c00000000000e7552620", expireAt))
+	require.NoError(t, err)
+	typeHash := crypto.Keccak256([]byte("bytes32 Sign to request a swap on Meson"))
+	require.Equal(t, crypto.Keccak256Hash(typeHash, crypto.Keccak256(common.FromHex(swap.Hex()))), swap.RequestHash())
+
+	// 非类型化签名与personal_sign对encoded的签名一致
+	nonTyped, err := DecodeEncodedSwap(testSwapEncoded("c80000000000e7552620", expireAt))
+	require.NoError(t, err)
+	require.Equal(t, common.BytesToHash(accounts.TextHash(common.FromHex(nonTyped.Hex()))), nonTyped.RequestHash())
+}
+
+func TestSubmitOffline_RejectsMismatchedApprove(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	r := newOfflineRequest(t, crypto.PubkeyToAddress(key.PublicKey).Hex())
+
+	bridge := NewBridge()
+	_, err = bridge.SubmitOffline(context.Background(), r)
+	require.ErrorContains(t, err, "尚未签名")
+
+	// 签名后修改待签名交易，提交时在广播前拒绝，记录已保存并带有失败原因
+	require.NoError(t, SignOffline(r, key, common.HexToAddress(PoolAddress)))
+	r.Approve.Nonce++
+	record, err := bridge.SubmitOffline(context.Background(), r)
+	require.ErrorContains(t, err, "不一致")
+	saved, err := bridge.SwapStore().Get(record.ID)
+	require.NoError(t, err)
+	require.Equal(t, StatusSigned, saved.Status)
+	require.Contains(t, saved.Error, "不一致")
+
+	// 重复提交使用同一条记录
+	again, err := bridge.SubmitOffline(context.Background(), r)
+	require.Error(t, err)
+	require.Equal(t, record.ID, again.ID)
+	records, err := bridge.SwapStore().List()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+}
+
+func TestExportOffline_DoesNotMutateRequest(t *testing.T) {
+	req := &TransferRequest{FromAddress: "0x2F913C820ed3bEb3a67391a6eFF64E70c4B20b19", Amount: mustTokenAmount(t, "6", MesonDecimals)}
+	bridge := NewBridge()
+	bridge.currentChain = ChainMerlin
+	// 没有连接节点，查询授权额度时失败
+	_, err := bridge.ExportOffline(context.Background(), req, nil)
+	require.Error(t, err)
+	require.Equal(t, Chain(""), req.FromChain)
+}
+
+func TestCheckSignedTx(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey).Hex()
+	unsigned, chainID, err := newOfflineRequest(t, from).Approve.transaction()
+	require.NoError(t, err)
+
+	// tamper按待签名交易修改一个字段后签名
+	sign := func(tamper func(tx *types.LegacyTx), signer types.Signer, key *ecdsa.PrivateKey) *types.Transaction {
+		to := *unsigned.To()
+		inner := &types.LegacyTx{Nonce: unsigned.Nonce(), To: &to, Value: unsigned.Value(), Gas: unsigned.Gas(), GasPrice: unsigned.GasPrice(), Data: unsigned.Data()}
+		if tamper != nil {
+			tamper(inner)
+		}
+		signed, err := types.SignNewTx(key, signer, inner)
+		require.NoError(t, err)
+		return signed
+	}
+	eip155 := types.NewEIP155Signer(chainID)
+
+	tests := []struct {
+		name   string
+		signed *types.Transaction
+		want   string
+	}{
+		{"same", sign(nil, eip155, key), ""},
+		{"other signer", sign(nil, eip155, other), "签名地址"},
+		{"other chain", sign(nil, types.NewEIP155Signer(big.NewInt(1)), key), "校验交易签名失败"},
+		{"without chain id", sign(nil, types.HomesteadSigner{}, key), "链ID"},
+		{"nonce", sign(func(tx *types.LegacyTx) { tx.Nonce++ }, eip155, key), "nonce"},
+		{"contract creation", sign(func(tx *types.LegacyTx) { tx.To = nil }, eip155, key), "接收地址"},
+		{"data", sign(func(tx *types.LegacyTx) { tx.Data = []byte{0x01} }, eip155, key), "调用数据"},
+		{"value", sign(func(tx *types.LegacyTx) { tx.Value = big.NewInt(1e18) }, eip155, key), "转账金额"},
+		{"gas", sign(func(tx *types.LegacyTx) { tx.Gas = 10_000_000 }, eip155, key), "gas上限"},
+		{"gas price", sign(func(tx *types.LegacyTx) { tx.GasPrice = big.NewInt(1e15) }, eip155, key), "gas价格"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSignedTx(tt.signed, unsigned, chainID, from)
+			if tt.want == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
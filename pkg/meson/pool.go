@@ -0,0 +1,343 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// DefaultLogChunkSize 分段查询日志时每段的默认区块数
+const DefaultLogChunkSize uint64 = 2000
+
+// Meson池合约ABI(MesonSwap和MesonPools部分)
+// 池合约通过require(条件, 原因)回滚，没有自定义错误，revert原因由helpers.DecodeRevert按Error(string)解码
+var mesonPoolABI = `[
+	{
+		"inputs": [
+			{"name": "encodedSwap", "type": "uint256"},
+			{"name": "r", "type": "bytes32"},
+			{"name": "yParityAndS", "type": "bytes32"},
+			{"name": "postingValue", "type": "uint200"}
+		],
+		"name": "postSwap",
+		"outputs": [],
+		"stateMutability": "payable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "encodedSwap", "type": "uint256"},
+			{"name": "postingValue", "type": "uint200"}
+		],
+		"name": "postSwapFromInitiator",
+		"outputs": [],
+		"stateMutability": "payable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "encodedSwap", "type": "uint256"},
+			{"name": "r", "type": "bytes32"},
+			{"name": "yParityAndS", "type": "bytes32"},
+			{"name": "recipient", "type": "address"},
+			{"name": "depositToPool", "type": "bool"}
+		],
+		"name": "executeSwap",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "encodedSwap", "type": "uint256"}
+		],
+		"name": "cancelSwap",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "encodedSwap", "type": "uint256"}
+		],
+		"name": "getPostedSwap",
+		"outputs": [
+			{"name": "initiator", "type": "address"},
+			{"name": "poolOwner", "type": "address"},
+			{"name": "exist", "type": "bool"}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "encodedSwap", "type": "uint256"},
+			{"name": "r", "type": "bytes32"},
+			{"name": "yParityAndS", "type": "bytes32"},
+			{"name": "initiator", "type": "address"},
+			{"name": "recipient", "type": "address"}
+		],
+		"name": "release",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "encodedSwap", "type": "uint256"},
+			{"name": "initiator", "type": "address"}
+		],
+		"name": "getLockedSwap",
+		"outputs": [
+			{"name": "poolOwner", "type": "address"},
+			{"name": "until", "type": "uint40"}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": true, "name": "encodedSwap", "type": "uint256"}
+		],
+		"name": "SwapPosted",
+		"type": "event"
+	},
+	{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": true, "name": "encodedSwap", "type": "uint256"}
+		],
+		"name": "SwapBonded",
+		"type": "event"
+	},
+	{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": true, "name": "encodedSwap", "type": "uint256"}
+		],
+		"name": "SwapExecuted",
+		"type": "event"
+	},
+	{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": true, "name": "encodedSwap", "type": "uint256"}
+		],
+		"name": "SwapCancelled",
+		"type": "event"
+	},
+	{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": true, "name": "encodedSwap", "type": "uint256"}
+		],
+		"name": "SwapLocked",
+		"type": "event"
+	},
+	{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": true, "name": "encodedSwap", "type": "uint256"}
+		],
+		"name": "SwapReleased",
+		"type": "event"
+	}
+]`
+
+// PostedSwap 源链池合约上已提交的跨链交易
+type PostedSwap struct {
+	Initiator common.Address // 发起人
+	PoolOwner common.Address // 绑定的LP，未绑定时为零地址
+	Exist     bool           // 是否存在(已执行或已取消的交易不存在)
+}
+
+// LockedSwap 目标链池合约上LP已锁定的跨链交易
+type LockedSwap struct {
+	PoolOwner common.Address // 锁定资金的LP，未锁定或已释放时为零地址
+	Until     int64          // 锁定截止时间(Unix秒)，超过后LP可以解锁
+}
+
+// Locked 是否处于锁定状态
+func (s *LockedSwap) Locked() bool {
+	return s.PoolOwner != (common.Address{})
+}
+
+// MesonPool Meson池合约接口
+type MesonPool struct {
+	address common.Address
+	abi     abi.ABI
+	client  *ethclient.Client
+}
+
+// NewMesonPool 创建池合约接口实例
+func NewMesonPool(client *ethclient.Client, address common.Address) (*MesonPool, error) {
+	parsed, err := abi.JSON(strings.NewReader(mesonPoolABI))
+	if err != nil {
+		return nil, fmt.Errorf("解析ABI失败: %w", err)
+	}
+
+	return &MesonPool{
+		address: address,
+		abi:     parsed,
+		client:  client,
+	}, nil
+}
+
+// Address 返回池合约地址
+func (p *MesonPool) Address() common.Address {
+	return p.address
+}
+
+// GetPostSwapData 返回postSwap调用的编码数据
+// poolIndex为0时交易提交后处于未绑定状态，等待LP绑定
+func (p *MesonPool) GetPostSwapData(encodedSwap *big.Int, sig []byte, initiator common.Address, poolIndex uint64) ([]byte, error) {
+	r, yParityAndS, err := splitSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	return p.abi.Pack("postSwap", encodedSwap, r, yParityAndS, postingValue(initiator, poolIndex))
+}
+
+// GetPostSwapFromInitiatorData 返回postSwapFromInitiator调用的编码数据，需由发起人自己发送
+func (p *MesonPool) GetPostSwapFromInitiatorData(encodedSwap *big.Int, initiator common.Address, poolIndex uint64) ([]byte, error) {
+	return p.abi.Pack("postSwapFromInitiator", encodedSwap, postingValue(initiator, poolIndex))
+}
+
+// GetExecuteSwapData 返回executeSwap调用的编码数据，sig为发起人的release签名
+func (p *MesonPool) GetExecuteSwapData(encodedSwap *big.Int, sig []byte, recipient common.Address, depositToPool bool) ([]byte, error) {
+	r, yParityAndS, err := splitSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	return p.abi.Pack("executeSwap", encodedSwap, r, yParityAndS, recipient, depositToPool)
+}
+
+// GetCancelSwapData 返回cancelSwap调用的编码数据
+func (p *MesonPool) GetCancelSwapData(encodedSwap *big.Int) ([]byte, error) {
+	return p.abi.Pack("cancelSwap", encodedSwap)
+}
+
+// GetPostedSwap 查询源链上已提交的跨链交易
+func (p *MesonPool) GetPostedSwap(ctx context.Context, encodedSwap *big.Int) (*PostedSwap, error) {
+	out, err := p.call(ctx, "getPostedSwap", encodedSwap)
+	if err != nil {
+		return nil, err
+	}
+	return &PostedSwap{
+		Initiator: out[0].(common.Address),
+		PoolOwner: out[1].(common.Address),
+		Exist:     out[2].(bool),
+	}, nil
+}
+
+// GetReleaseData 返回release调用的编码数据，sig为发起人的release签名
+func (p *MesonPool) GetReleaseData(encodedSwap *big.Int, sig []byte, initiator, recipient common.Address) ([]byte, error) {
+	r, yParityAndS, err := splitSignature(sig)
+	if err != nil {
+		return nil, err
+	}
+	return p.abi.Pack("release", encodedSwap, r, yParityAndS, initiator, recipient)
+}
+
+// GetLockedSwap 查询目标链上LP锁定的跨链交易
+func (p *MesonPool) GetLockedSwap(ctx context.Context, encodedSwap *big.Int, initiator common.Address) (*LockedSwap, error) {
+	out, err := p.call(ctx, "getLockedSwap", encodedSwap, initiator)
+	if err != nil {
+		return nil, err
+	}
+	return &LockedSwap{
+		PoolOwner: out[0].(common.Address),
+		Until:     out[1].(*big.Int).Int64(),
+	}, nil
+}
+
+// FilterSwapPosted 查询区块范围内提交的跨链交易，按chunkSize分段查询以避免超出节点限制
+func (p *MesonPool) FilterSwapPosted(ctx context.Context, fromBlock, toBlock, chunkSize uint64) ([]*big.Int, error) {
+	logs, err := p.filterLogs(ctx, fromBlock, toBlock, chunkSize, [][]common.Hash{{p.abi.Events["SwapPosted"].ID}})
+	if err != nil {
+		return nil, err
+	}
+	var encodedSwaps []*big.Int
+	for _, log := range logs {
+		if len(log.Topics) > 1 {
+			encodedSwaps = append(encodedSwaps, log.Topics[1].Big())
+		}
+	}
+	return encodedSwaps, nil
+}
+
+// filterLogs 分段查询池合约的日志
+func (p *MesonPool) filterLogs(ctx context.Context, fromBlock, toBlock, chunkSize uint64, topics [][]common.Hash) ([]types.Log, error) {
+	if chunkSize == 0 {
+		chunkSize = DefaultLogChunkSize
+	}
+	var logs []types.Log
+	for start := fromBlock; start <= toBlock; start += chunkSize {
+		end := start + chunkSize - 1
+		if end > toBlock {
+			end = toBlock
+		}
+		chunk, err := p.client.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{p.address},
+			Topics:    topics,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("查询区块%d-%d的日志失败: %w", start, end, err)
+		}
+		logs = append(logs, chunk...)
+	}
+	return logs, nil
+}
+
+// call 调用合约的只读方法
+func (p *MesonPool) call(ctx context.Context, method string, args ...interface{}) ([]interface{}, error) {
+	data, err := p.abi.Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("编码%s调用失败: %w", method, err)
+	}
+	result, err := p.client.CallContract(ctx, ethereum.CallMsg{To: &p.address, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("调用%s失败: %w", method, err)
+	}
+	out, err := p.abi.Unpack(method, result)
+	if err != nil {
+		return nil, fmt.Errorf("解析%s结果失败: %w", method, err)
+	}
+	return out, nil
+}
+
+// postingValue 将发起人地址和池索引打包为uint200: initiator(160位) | poolIndex(40位)
+func postingValue(initiator common.Address, poolIndex uint64) *big.Int {
+	value := new(big.Int).SetBytes(initiator.Bytes())
+	value.Lsh(value, 40)
+	return value.Or(value, new(big.Int).SetUint64(poolIndex&0xFFFFFFFFFF))
+}
+
+// splitSignature 将65字节签名转换为合约使用的(r, yParityAndS)紧凑格式(EIP-2098)
+func splitSignature(sig []byte) ([32]byte, [32]byte, error) {
+	var r, yParityAndS [32]byte
+	if len(sig) != 65 {
+		return r, yParityAndS, fmt.Errorf("无效的签名长度: %d", len(sig))
+	}
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return r, yParityAndS, fmt.Errorf("无效的签名v值: %d", sig[64])
+	}
+	copy(r[:], sig[:32])
+	copy(yParityAndS[:], sig[32:64])
+	yParityAndS[0] |= v << 7
+	return r, yParityAndS, nil
+}
@@ -0,0 +1,328 @@
+package meson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+)
+
+// ErrUnsupportedToken 中继器不支持该链或代币
+var ErrUnsupportedToken = errors.New("中继器不支持该代币")
+
+// ErrPreflightFailed 预检发现了问题
+var ErrPreflightFailed = errors.New("预检未通过")
+
+// maxEncodedAmount encoded中金额字段(5字节，6位小数)可表示的最大值
+var maxEncodedAmount = new(big.Int).SetUint64(1<<40 - 1)
+
+const (
+	// expiryMargin 从编码到提交上链预留的时间
+	expiryMargin = 5 * time.Minute
+	// maxClockSkew 本地时间与链上区块时间允许的最大偏差
+	maxClockSkew = 5 * time.Minute
+	// approveGasFallback 无法估算时approve交易的gas上限
+	approveGasFallback uint64 = 60000
+)
+
+// PreflightCheck 预检项目
+type PreflightCheck string
+
+const (
+	CheckAddress   PreflightCheck = "address"   // 地址格式
+	CheckToken     PreflightCheck = "token"     // 源链代币和池合约
+	CheckRoute     PreflightCheck = "route"     // 中继器是否支持该路径
+	CheckAmount    PreflightCheck = "amount"    // 金额精度和中继器的单笔限制
+	CheckBalance   PreflightCheck = "balance"   // 代币余额
+	CheckAllowance PreflightCheck = "allowance" // 授权额度
+	CheckGas       PreflightCheck = "gas"       // 原生代币余额
+	CheckExpiry    PreflightCheck = "expiry"    // 过期时间和链上时钟
+)
+
+// PreflightProblem 预检发现的问题
+type PreflightProblem struct {
+	Check   PreflightCheck `json:"check"`
+	Message string         `json:"message"`
+}
+
+// PreflightReport 预检结果，Problems为空时表示可以发起转账
+type PreflightReport struct {
+	FromAddress   string          `json:"fromAddress"`
+	TokenAddress  string          `json:"tokenAddress,omitempty"`
+	TokenBalance  decimal.Decimal `json:"tokenBalance"`
+	Allowance     decimal.Decimal `json:"allowance"`
+	NeedsApprove  bool            `json:"needsApprove"`
+	NativeBalance decimal.Decimal `json:"nativeBalance"` // 原生代币余额(18位小数)
+	EstimatedGas  decimal.Decimal `json:"estimatedGas"`  // 预计消耗的原生代币
+	Price         *PriceResponse  `json:"price,omitempty"`
+	ExpireAt      time.Time       `json:"expireAt"`
+
+	Problems []PreflightProblem `json:"problems"`
+}
+
+// OK 是否没有发现问题
+func (r *PreflightReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Err 将所有问题合并为一个错误，没有问题时返回nil
+func (r *PreflightReport) Err() error {
+	return problemsErr(r.Problems)
+}
+
+// problemsErr 将问题合并为包装ErrPreflightFailed的错误，没有问题时返回nil
+func problemsErr(problems []PreflightProblem) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(problems))
+	for _, problem := range problems {
+		messages = append(messages, fmt.Sprintf("[%s] %s", problem.Check, problem.Message))
+	}
+	return fmt.Errorf("%w: %s", ErrPreflightFailed, strings.Join(messages, "; "))
+}
+
+func (r *PreflightReport) add(check PreflightCheck, format string, args ...interface{}) {
+	r.Problems = append(r.Problems, PreflightProblem{Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+// Preflight 在签名之前检查转账能否成功: 代币余额、授权额度、支付gas的原生代币余额、
+// 中继器对路径和金额的限制、源链代币支持以及过期时间，一次列出所有问题
+// 单项查询失败也作为问题记录，只有请求本身无效时才返回error
+func (b *Bridge) Preflight(ctx context.Context, req *TransferRequest) (*PreflightReport, error) {
+	if req.FromAddress == "" {
+		return nil, fmt.Errorf("预检需要提供发送地址")
+	}
+
+	report := &PreflightReport{FromAddress: req.FromAddress}
+	recipient := req.Recipient
+	if recipient == "" {
+		recipient = req.FromAddress
+	}
+	if err := b.validateAddresses(req.FromAddress, recipient); err != nil {
+		report.add(CheckAddress, "%v", err)
+		return report, nil
+	}
+	from := common.HexToAddress(req.FromAddress)
+
+	b.checkAmount(report, req.Amount)
+	b.checkTokens(report, req)
+	b.checkRoute(report, req)
+	b.checkExpiry(ctx, report, req)
+
+	client, err := b.clientFor(req.FromChain)
+	if err != nil {
+		report.add(CheckToken, "%v", err)
+		return report, nil
+	}
+	tokenAddr, err := b.tokenAddress(req.FromChain, req.FromToken)
+	if err != nil {
+		report.add(CheckToken, "%v", err)
+		return report, nil
+	}
+	report.TokenAddress = tokenAddr.Hex()
+	poolAddr, exists := b.poolAddrs[req.FromChain]
+	if !exists {
+		report.add(CheckToken, "未知的链: %s，请先注册池地址", req.FromChain)
+		return report, nil
+	}
+
+	erc20, err := NewERC20(client, tokenAddr)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := erc20.Decimals(ctx)
+	if err != nil {
+		report.add(CheckToken, "查询代币精度失败: %v", err)
+		return report, nil
+	}
+	tokenAmount, err := req.Amount.ToDecimals(int32(decimals))
+	if err != nil {
+		report.add(CheckAmount, "%v", err)
+		return report, nil
+	}
+	needed := tokenAmount.BaseUnits()
+
+	if balance, err := erc20.BalanceOf(ctx, from); err != nil {
+		report.add(CheckBalance, "查询代币余额失败: %v", err)
+	} else {
+		report.TokenBalance = TokenAmountFromBase(balance, int32(decimals)).Decimal()
+		if balance.Cmp(needed) < 0 {
+			report.add(CheckBalance, "代币余额不足: 余额%s，需要%s", report.TokenBalance, req.Amount)
+		}
+	}
+
+	var gasLimit uint64
+	if allowance, err := erc20.Allowance(ctx, from, poolAddr); err != nil {
+		report.add(CheckAllowance, "查询授权额度失败: %v", err)
+	} else {
+		report.Allowance = TokenAmountFromBase(allowance, int32(decimals)).Decimal()
+		report.NeedsApprove = allowance.Cmp(needed) < 0
+		if report.NeedsApprove {
+			if req.SkipApprove {
+				report.add(CheckAllowance, "授权额度不足且跳过了授权: 已授权%s，需要%s", report.Allowance, req.Amount)
+			} else {
+				gasLimit = b.estimateApproveGas(ctx, erc20, from, poolAddr)
+			}
+		}
+	}
+
+	native, err := client.BalanceAt(ctx, from, nil)
+	if err != nil {
+		report.add(CheckGas, "查询原生代币余额失败: %v", err)
+		return report, nil
+	}
+	report.NativeBalance = decimal.NewFromBigInt(native, -18)
+	if gasLimit > 0 {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			report.add(CheckGas, "获取gas价格失败: %v", err)
+			return report, nil
+		}
+		cost := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit))
+		report.EstimatedGas = decimal.NewFromBigInt(cost, -18)
+		if native.Cmp(cost) < 0 {
+			report.add(CheckGas, "原生代币余额不足以支付授权交易的gas: 余额%s，预计需要%s", report.NativeBalance, report.EstimatedGas)
+		}
+	}
+
+	return report, nil
+}
+
+// checkAmount 检查金额的精度以及encoded能否表示
+func (b *Bridge) checkAmount(report *PreflightReport, amount TokenAmount) {
+	if amount.IsZero() {
+		report.add(CheckAmount, "金额必须大于0: %s", amount)
+		return
+	}
+	mesonAmount, err := amount.MesonAmount()
+	if err != nil {
+		report.add(CheckAmount, "%v", err)
+		return
+	}
+	if mesonAmount.BaseUnits().Cmp(maxEncodedAmount) > 0 {
+		report.add(CheckAmount, "金额超出跨链交易可表示的范围: %s", amount)
+	}
+}
+
+// checkTokens 按中继器支持的链和代币列表检查源链代币、目标链代币，以及源链代币的单笔金额限制
+func (b *Bridge) checkTokens(report *PreflightReport, req *TransferRequest) {
+	chains, err := b.client.ListChains()
+	if err != nil {
+		report.add(CheckRoute, "查询中继器支持的代币失败: %v", err)
+		return
+	}
+	if _, err := findToken(chains, req.ToChain, req.ToToken); err != nil {
+		report.add(CheckRoute, "目标代币: %v", err)
+	}
+	source, err := findToken(chains, req.FromChain, req.FromToken)
+	if err != nil {
+		report.add(CheckRoute, "源链代币: %v", err)
+		return
+	}
+	checkTokenLimits(report, source, req.Amount)
+}
+
+// checkTokenLimits 检查金额是否在中继器给出的单笔最小和最大金额之间
+func checkTokenLimits(report *PreflightReport, token *TokenInfo, amount TokenAmount) {
+	if token.Min != "" {
+		min, err := decimal.NewFromString(token.Min)
+		if err != nil {
+			report.add(CheckRoute, "中继器返回的最小金额无效: %s", token.Min)
+		} else if amount.Decimal().LessThan(min) {
+			report.add(CheckAmount, "金额低于中继器单笔下限%s: %s", min, amount)
+		}
+	}
+	if token.Max != "" {
+		max, err := decimal.NewFromString(token.Max)
+		if err != nil {
+			report.add(CheckRoute, "中继器返回的最大金额无效: %s", token.Max)
+		} else if amount.Decimal().GreaterThan(max) {
+			report.add(CheckAmount, "金额超过中继器单笔上限%s: %s", max, amount)
+		}
+	}
+}
+
+// findToken 在中继器支持的列表中查找链上的代币，代币可以是id、符号或代币索引
+func findToken(chains []ChainInfo, chain Chain, token Token) (*TokenInfo, error) {
+	for i := range chains {
+		if !strings.EqualFold(chains[i].ID, string(chain)) {
+			continue
+		}
+		for j := range chains[i].Tokens {
+			info := &chains[i].Tokens[j]
+			if strings.EqualFold(info.ID, string(token)) || strings.EqualFold(info.Symbol, string(token)) ||
+				(info.TokenIndex > 0 && strconv.Itoa(info.TokenIndex) == string(token)) {
+				return info, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: 链%s上的%s", ErrUnsupportedToken, chain, token)
+	}
+	return nil, fmt.Errorf("%w: 链%s", ErrUnsupportedToken, chain)
+}
+
+// checkRoute 向中继器询价，确认支持该路径并且金额足以支付手续费
+func (b *Bridge) checkRoute(report *PreflightReport, req *TransferRequest) {
+	if _, err := req.Amount.MesonAmount(); err != nil {
+		return // 精度问题已在checkAmount中记录
+	}
+	price, err := b.GetPrice(req.Amount, req.FromAddress, req.FromChain, req.ToChain, req.FromToken, req.ToToken)
+	if err != nil {
+		report.add(CheckRoute, "中继器不支持该路径或金额超出限制: %v", err)
+		return
+	}
+	report.Price = price
+
+	totalFee, err := decimal.NewFromString(price.TotalFee)
+	if err == nil && totalFee.GreaterThanOrEqual(req.Amount.Decimal()) {
+		report.add(CheckAmount, "金额%s不足以支付手续费%s", req.Amount, totalFee)
+	}
+}
+
+// checkExpiry 检查有效期是否在池合约允许的范围内，以及本地时钟与链上时间是否一致
+func (b *Bridge) checkExpiry(ctx context.Context, report *PreflightReport, req *TransferRequest) {
+	expireIn := req.ExpireIn
+	if expireIn == 0 {
+		expireIn = DefaultSwapExpiry
+	}
+	report.ExpireAt = time.Now().Add(expireIn)
+	if expireIn < MinBondPeriod+expiryMargin || expireIn >= MaxBondPeriod {
+		report.add(CheckExpiry, "有效期%s超出允许范围，需在%s到%s之间", expireIn, MinBondPeriod+expiryMargin, MaxBondPeriod)
+	}
+
+	client, err := b.clientFor(req.FromChain)
+	if err != nil {
+		return
+	}
+	header, err := client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		report.add(CheckExpiry, "获取最新区块失败: %v", err)
+		return
+	}
+	skew := time.Since(time.Unix(int64(header.Time), 0))
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		report.add(CheckExpiry, "本地时间与链上区块时间相差%s，过期时间可能不准确", skew.Round(time.Second))
+	}
+}
+
+// estimateApproveGas 估算approve交易的gas，失败时使用保守值
+func (b *Bridge) estimateApproveGas(ctx context.Context, erc20 *ERC20, from, spender common.Address) uint64 {
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	data, err := erc20.GetApproveData(spender, maxUint256)
+	if err != nil {
+		return approveGasFallback
+	}
+	gas, err := helpers.EstimateGas(ctx, erc20.client, from, &helpers.TxData{To: erc20.address, Data: data})
+	if err != nil {
+		return approveGasFallback
+	}
+	return gas
+}
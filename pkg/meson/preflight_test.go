@@ -0,0 +1,81 @@
+package meson
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreflight_AmountAndExpiry(t *testing.T) {
+	bridge := NewBridge()
+	report := &PreflightReport{}
+
+	bridge.checkAmount(report, mustTokenAmount(t, "1.1234567", 18))
+	bridge.checkAmount(report, mustTokenAmount(t, "2000000", 18))
+	bridge.checkAmount(report, TokenAmount{})
+	bridge.checkAmount(report, mustTokenAmount(t, "1.5", 18))
+	require.Len(t, report.Problems, 3)
+	for _, problem := range report.Problems {
+		require.Equal(t, CheckAmount, problem.Check)
+	}
+
+	// 未连接源链时只检查有效期范围
+	report = &PreflightReport{}
+	bridge.checkExpiry(context.Background(), report, &TransferRequest{ExpireIn: 30 * time.Minute})
+	bridge.checkExpiry(context.Background(), report, &TransferRequest{})
+	require.Len(t, report.Problems, 1)
+	require.Equal(t, CheckExpiry, report.Problems[0].Check)
+	require.Error(t, report.Err())
+	require.Nil(t, (&PreflightReport{}).Err())
+}
+
+func TestPreflight_RelayerTokens(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/list", r.URL.Path)
+		w.Write([]byte(`{"result": [
+			{"id": "merlin", "tokens": [{"id": "merl", "tokenIndex": 69, "min": "1", "max": "5000"}, {"id": "mbtc", "tokenIndex": 67}]},
+			{"id": "bnb", "tokens": [{"id": "merl", "tokenIndex": 69}]}
+		]}`))
+	}))
+	defer srv.Close()
+	bridge := NewBridge()
+	bridge.client.baseURL = srv.URL
+
+	tests := []struct {
+		name     string
+		req      *TransferRequest
+		problems []PreflightCheck
+	}{
+		{"ok", &TransferRequest{FromChain: ChainMerlin, FromToken: TokenMERL, ToChain: "bnb", ToToken: "merl", Amount: mustTokenAmount(t, "6", 18)}, nil},
+		{"no limits", &TransferRequest{FromChain: ChainMerlin, FromToken: "MBTC", ToChain: "bnb", ToToken: "69", Amount: mustTokenAmount(t, "0.001", 18)}, nil},
+		{"below min", &TransferRequest{FromChain: ChainMerlin, FromToken: TokenMERL, ToChain: "bnb", ToToken: "merl", Amount: mustTokenAmount(t, "0.5", 18)}, []PreflightCheck{CheckAmount}},
+		{"above max", &TransferRequest{FromChain: ChainMerlin, FromToken: TokenMERL, ToChain: "bnb", ToToken: "merl", Amount: mustTokenAmount(t, "5000.01", 18)}, []PreflightCheck{CheckAmount}},
+		{"unsupported destination token", &TransferRequest{FromChain: ChainMerlin, FromToken: TokenMERL, ToChain: "bnb", ToToken: TokenMBTC, Amount: mustTokenAmount(t, "6", 18)}, []PreflightCheck{CheckRoute}},
+		{"unsupported destination chain", &TransferRequest{FromChain: ChainMerlin, FromToken: TokenMERL, ToChain: "eth", ToToken: "merl", Amount: mustTokenAmount(t, "6", 18)}, []PreflightCheck{CheckRoute}},
+		{"unsupported source", &TransferRequest{FromChain: ChainZksync, FromToken: TokenMERL, ToChain: "bnb", ToToken: "merl", Amount: mustTokenAmount(t, "6", 18)}, []PreflightCheck{CheckRoute}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &PreflightReport{}
+			bridge.checkTokens(report, tt.req)
+			var checks []PreflightCheck
+			for _, problem := range report.Problems {
+				checks = append(checks, problem.Check)
+			}
+			require.Equal(t, tt.problems, checks, report.Problems)
+		})
+	}
+
+	_, err := findToken(nil, "bnb", "merl")
+	require.ErrorIs(t, err, ErrUnsupportedToken)
+}
+
+func mustTokenAmount(t *testing.T, s string, decimals int32) TokenAmount {
+	amount, err := ParseTokenAmount(s, decimals)
+	require.NoError(t, err)
+	return amount
+}
@@ -0,0 +1,85 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+)
+
+// GetLockedSwap 查询目标链上LP为该跨链交易锁定的资金
+// ref可以是swapId或encoded，initiator为源链上的发起人
+func (b *Bridge) GetLockedSwap(ctx context.Context, chain Chain, ref, initiator string) (*LockedSwap, error) {
+	if err := b.validateAddresses(initiator); err != nil {
+		return nil, err
+	}
+	swap, err := b.resolveEncodedSwap(ref)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := b.pool(chain)
+	if err != nil {
+		return nil, err
+	}
+	return pool.GetLockedSwap(ctx, swap.Int(), common.HexToAddress(initiator))
+}
+
+// GetReleaseData 获取在目标链池合约上释放已锁定资金(release)的调用数据
+// 中继器未调用release时，接收方或运维程序可以用发起人的release签名自行提交
+// chain为目标链，需先通过AddChainClient连接并注册池地址
+func (b *Bridge) GetReleaseData(ctx context.Context, chain Chain, ref, initiator, recipient string, sig []byte) (*helpers.TxData, error) {
+	if err := b.validateAddresses(initiator, recipient); err != nil {
+		return nil, err
+	}
+	swap, err := b.resolveEncodedSwap(ref)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := b.pool(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查LP是否已锁定资金且锁定未过期
+	initiatorAddr := common.HexToAddress(initiator)
+	locked, err := pool.GetLockedSwap(ctx, swap.Int(), initiatorAddr)
+	if err != nil {
+		return nil, fmt.Errorf("查询锁定状态失败: %w", err)
+	}
+	if !locked.Locked() {
+		return nil, fmt.Errorf("跨链交易在%s链上未被锁定或已释放", chain)
+	}
+	head, err := pool.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取最新区块失败: %w", err)
+	}
+	if head.Time >= uint64(locked.Until) {
+		return nil, fmt.Errorf("锁定已于%s过期，无法释放", time.Unix(locked.Until, 0).Format(time.RFC3339))
+	}
+
+	data, err := pool.GetReleaseData(swap.Int(), sig, initiatorAddr, common.HexToAddress(recipient))
+	if err != nil {
+		return nil, fmt.Errorf("生成release数据失败: %w", err)
+	}
+	return &helpers.TxData{
+		To:   pool.Address(),
+		Data: data,
+	}, nil
+}
+
+// ReleaseSwap 在目标链上释放LP已锁定的资金给接收方
+// sender需连接目标链，任何地址都可以提交，资金始终转给签名中指定的recipient
+func (b *Bridge) ReleaseSwap(ctx context.Context, sender *helpers.Sender, chain Chain, ref, initiator, recipient string, sig []byte) (*helpers.TxResult, error) {
+	txData, err := b.GetReleaseData(ctx, chain, ref, initiator, recipient, sig)
+	if err != nil {
+		return nil, err
+	}
+	result, err := sender.Send(ctx, txData)
+	if err != nil {
+		return result, fmt.Errorf("发送release交易失败: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package meson
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// testEncoded 6 MERL从merlin到bnb，salt未设置非类型化签名标志
+const testEncoded = "0x01" + "00005b8d80" + "c00000000000e7552620" + "0000000000" + "006592116a" + "02ca" + "45" + "1068" + "45"
+
+func TestReleaseHash(t *testing.T) {
+	recipient := common.HexToAddress("0x2F913C820ed3bEb3a67391a6eFF64E70c4B20b19")
+
+	swap, err := DecodeEncodedSwap(testEncoded)
+	require.NoError(t, err)
+	require.False(t, swap.signNonTyped())
+	hash := swap.ReleaseHash(recipient)
+	require.Equal(t, "0x0eca807cf8d859f36fa04c2982c8da6b3f36df543c50e6276e9fc03432ee15aa", hash.Hex())
+	typeHash := crypto.Keccak256([]byte("bytes32 Sign to release a swap on Meson" + "address Recipient"))
+	require.Equal(t, crypto.Keccak256Hash(typeHash, crypto.Keccak256(common.FromHex(testEncoded), recipient[:])), hash)
+
+	// 非类型化签名与personal_sign对encoded和recipient拼接后的52字节签名一致
+	nonTyped, err := DecodeEncodedSwap("0x01" + "00005b8d80" + "c80000000000e7552620" + "0000000000" + "006592116a" + "02ca" + "45" + "1068" + "45")
+	require.NoError(t, err)
+	require.True(t, nonTyped.signNonTyped())
+	message := append(common.FromHex(nonTyped.Hex()), recipient[:]...)
+	require.Equal(t, common.BytesToHash(accounts.TextHash(message)), nonTyped.ReleaseHash(recipient))
+
+	// 不同的接收地址签名哈希不同
+	require.NotEqual(t, hash, swap.ReleaseHash(common.HexToAddress("0x1111111111111111111111111111111111111111")))
+}
+
+func TestGetReleaseHash(t *testing.T) {
+	b := NewBridge()
+	swap, err := DecodeEncodedSwap(testEncoded)
+	require.NoError(t, err)
+	recipient := "0x2F913C820ed3bEb3a67391a6eFF64E70c4B20b19"
+
+	hash, err := b.GetReleaseHash(testEncoded, recipient)
+	require.NoError(t, err)
+	require.Equal(t, swap.ReleaseHash(common.HexToAddress(recipient)), hash)
+
+	_, err = b.GetReleaseHash(testEncoded, "0x123")
+	require.Error(t, err)
+}
+
+func TestReleaseData(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	initiator := crypto.PubkeyToAddress(key.PublicKey)
+	recipient := common.HexToAddress("0x2F913C820ed3bEb3a67391a6eFF64E70c4B20b19")
+	swap, err := DecodeEncodedSwap(testEncoded)
+	require.NoError(t, err)
+
+	hash := swap.ReleaseHash(recipient)
+	sig, err := crypto.Sign(hash[:], key)
+	require.NoError(t, err)
+	pub, err := crypto.SigToPub(hash[:], sig)
+	require.NoError(t, err)
+	require.Equal(t, initiator, crypto.PubkeyToAddress(*pub))
+
+	pool, err := NewMesonPool(nil, common.HexToAddress(PoolAddress))
+	require.NoError(t, err)
+	data, err := pool.GetReleaseData(swap.Int(), sig, initiator, recipient)
+	require.NoError(t, err)
+
+	method := pool.abi.Methods["release"]
+	require.Equal(t, method.ID, data[:4])
+	args, err := method.Inputs.Unpack(data[4:])
+	require.NoError(t, err)
+	require.Equal(t, swap.Int(), args[0].(*big.Int))
+	require.Equal(t, initiator, args[3].(common.Address))
+	require.Equal(t, recipient, args[4].(common.Address))
+
+	_, err = pool.GetReleaseData(swap.Int(), sig[:64], initiator, recipient)
+	require.Error(t, err)
+}
@@ -0,0 +1,167 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+)
+
+// RouteCandidate 候选的源链和代币
+type RouteCandidate struct {
+	Chain       Chain
+	Token       Token
+	FromAddress string // 该链上的发送地址
+}
+
+// Route 一条候选路径的报价
+type Route struct {
+	From        ChainTokenKey  `json:"from"`
+	To          ChainTokenKey  `json:"to"`
+	FromAddress string         `json:"fromAddress"`
+	Price       *PriceResponse `json:"price,omitempty"`
+
+	Fee        decimal.Decimal `json:"fee"`        // 中继器总手续费
+	GasNative  decimal.Decimal `json:"gasNative"`  // 预计源链gas，单位为原生代币
+	GasCost    decimal.Decimal `json:"gasCost"`    // 折算为转账代币单位的gas
+	GasPriced  bool            `json:"gasPriced"`  // 是否设置了原生代币价格用于折算
+	TotalCost  decimal.Decimal `json:"totalCost"`  // 手续费加gas
+	Comparable bool            `json:"comparable"` // 总成本是否包含全部gas，需要授权但未设置原生代币价格时为false
+	Balance    decimal.Decimal `json:"balance"`    // 源链代币余额
+	Sufficient bool            `json:"sufficient"` // 余额是否足够
+
+	Err   error  `json:"-"`
+	Error string `json:"error,omitempty"`
+}
+
+// SetNativePrice 设置链上原生代币以转账代币计价的价格，用于把gas折算到统一单位比较路径
+// 例如在Merlin链上转MBTC时，原生代币BTC对MBTC的价格为1
+func (b *Bridge) SetNativePrice(chain Chain, price decimal.Decimal) {
+	b.nativePrices.Store(chain, price)
+}
+
+// QuoteRoutes 并发查询各候选源链到目标的报价，计入源链gas后按总成本和可用余额排序
+// 排序规则: 查询成功的在前，余额足够的在前，总成本可比较的在前，总成本低的在前，余额多的在前
+// 需要授权gas但未通过SetNativePrice设置价格的路径总成本不含gas，不与其他路径比较总成本
+func (b *Bridge) QuoteRoutes(ctx context.Context, dest ChainTokenKey, amount TokenAmount, candidates []RouteCandidate) []*Route {
+	routes := make([]*Route, len(candidates))
+	var wg sync.WaitGroup
+	for i, candidate := range candidates {
+		wg.Add(1)
+		go func(i int, candidate RouteCandidate) {
+			defer wg.Done()
+			route := &Route{
+				From:        ChainTokenKey{Chain: candidate.Chain, Token: candidate.Token},
+				To:          dest,
+				FromAddress: candidate.FromAddress,
+			}
+			if err := b.quoteRoute(ctx, route, amount); err != nil {
+				route.Err = err
+				route.Error = err.Error()
+			}
+			routes[i] = route
+		}(i, candidate)
+	}
+	wg.Wait()
+
+	sortRoutes(routes)
+	return routes
+}
+
+// sortRoutes 按查询是否成功、余额是否足够、总成本是否可比较、总成本和余额排序
+func sortRoutes(routes []*Route) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, c := routes[i], routes[j]
+		if (a.Err == nil) != (c.Err == nil) {
+			return a.Err == nil
+		}
+		if a.Sufficient != c.Sufficient {
+			return a.Sufficient
+		}
+		if a.Comparable != c.Comparable {
+			return a.Comparable
+		}
+		if a.Comparable && !a.TotalCost.Equal(c.TotalCost) {
+			return a.TotalCost.LessThan(c.TotalCost)
+		}
+		return a.Balance.GreaterThan(c.Balance)
+	})
+}
+
+// quoteRoute 查询单条路径的手续费、余额和授权所需的gas
+func (b *Bridge) quoteRoute(ctx context.Context, route *Route, amount TokenAmount) error {
+	if !common.IsHexAddress(route.FromAddress) {
+		return fmt.Errorf("无效的地址格式: %s", route.FromAddress)
+	}
+	from := common.HexToAddress(route.FromAddress)
+
+	price, err := b.GetPrice(amount, route.FromAddress, route.From.Chain, route.To.Chain, route.From.Token, route.To.Token)
+	if err != nil {
+		return fmt.Errorf("询价失败: %w", err)
+	}
+	route.Price = price
+	route.Fee, err = decimal.NewFromString(price.TotalFee)
+	if err != nil {
+		return fmt.Errorf("无效的手续费: %s", price.TotalFee)
+	}
+
+	client, err := b.clientFor(route.From.Chain)
+	if err != nil {
+		return err
+	}
+	tokenAddr, err := b.tokenAddress(route.From.Chain, route.From.Token)
+	if err != nil {
+		return err
+	}
+	poolAddr, exists := b.poolAddrs[route.From.Chain]
+	if !exists {
+		return fmt.Errorf("未知的链: %s，请先注册池地址", route.From.Chain)
+	}
+
+	erc20, err := NewERC20(client, tokenAddr)
+	if err != nil {
+		return err
+	}
+	decimals, err := erc20.Decimals(ctx)
+	if err != nil {
+		return fmt.Errorf("查询代币精度失败: %w", err)
+	}
+	tokenAmount, err := amount.ToDecimals(int32(decimals))
+	if err != nil {
+		return err
+	}
+	balance, err := erc20.BalanceOf(ctx, from)
+	if err != nil {
+		return fmt.Errorf("查询代币余额失败: %w", err)
+	}
+	route.Balance = TokenAmountFromBase(balance, int32(decimals)).Decimal()
+	route.Sufficient = balance.Cmp(tokenAmount.BaseUnits()) >= 0
+
+	// 中继器代为提交交易，源链上只有授权需要用户支付gas
+	allowance, err := erc20.Allowance(ctx, from, poolAddr)
+	if err != nil {
+		return fmt.Errorf("查询授权额度失败: %w", err)
+	}
+	if allowance.Cmp(tokenAmount.BaseUnits()) < 0 {
+		gasPrice, err := client.SuggestGasPrice(ctx)
+		if err != nil {
+			return fmt.Errorf("获取gas价格失败: %w", err)
+		}
+		gas := b.estimateApproveGas(ctx, erc20, from, poolAddr)
+		route.GasNative = decimal.NewFromBigInt(new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gas)), -18)
+	}
+
+	route.TotalCost = route.Fee
+	if price, ok := b.nativePrices.Load(route.From.Chain); ok {
+		route.GasPriced = true
+		route.GasCost = route.GasNative.Mul(price.(decimal.Decimal))
+		route.TotalCost = route.TotalCost.Add(route.GasCost)
+	}
+	// 无需授权时总成本就是手续费，否则只有折算了gas才能与其他路径比较
+	route.Comparable = route.GasPriced || route.GasNative.IsZero()
+	return nil
+}
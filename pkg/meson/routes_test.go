@@ -0,0 +1,24 @@
+package meson
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortRoutes(t *testing.T) {
+	failed := &Route{From: ChainTokenKey{Chain: "eth"}, Err: errors.New("询价失败")}
+	poor := &Route{From: ChainTokenKey{Chain: "bnb"}, TotalCost: decimal.RequireFromString("0.1"), Comparable: true}
+	cheap := &Route{From: ChainTokenKey{Chain: ChainMerlin}, TotalCost: decimal.RequireFromString("0.2"), Sufficient: true, Comparable: true, Balance: decimal.NewFromInt(5)}
+	richer := &Route{From: ChainTokenKey{Chain: ChainZksync}, TotalCost: decimal.RequireFromString("0.2"), Sufficient: true, Comparable: true, Balance: decimal.NewFromInt(50)}
+	costly := &Route{From: ChainTokenKey{Chain: ChainDuckchain}, TotalCost: decimal.RequireFromString("0.3"), Sufficient: true, Comparable: true}
+	// 未折算gas的路径总成本只有手续费，不能与其他路径比较
+	unpriced := &Route{From: ChainTokenKey{Chain: "arb"}, TotalCost: decimal.RequireFromString("0.01"), Sufficient: true, Balance: decimal.NewFromInt(1)}
+	unpricedRicher := &Route{From: ChainTokenKey{Chain: "op"}, TotalCost: decimal.RequireFromString("0.5"), Sufficient: true, Balance: decimal.NewFromInt(9)}
+
+	routes := []*Route{failed, unpriced, poor, costly, unpricedRicher, cheap, richer}
+	sortRoutes(routes)
+	require.Equal(t, []*Route{richer, cheap, costly, unpricedRicher, unpriced, poor, failed}, routes)
+}
@@ -0,0 +1,52 @@
+package meson
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// 池合约校验签名时使用的类型哈希(主网)，与MesonHelpers合约一致
+var (
+	requestTypeHash = crypto.Keccak256([]byte("bytes32 Sign to request a swap on Meson"))
+	releaseTypeHash = crypto.Keccak256([]byte("bytes32 Sign to release a swap on Mesonaddress Recipient"))
+)
+
+// signNonTyped encoded的salt中是否设置了非类型化签名标志，设置时按personal_sign格式签名
+func (s *EncodedSwap) signNonTyped() bool {
+	return s.Salt[0]&0x08 != 0
+}
+
+// RequestHash 返回发起人提交跨链交易时需要签名的哈希，应与中继器返回的SigningRequest.Hash一致
+// 类型化签名为 keccak256(typeHash, keccak256(encoded))，
+// 非类型化签名为 keccak256("\x19Ethereum Signed Message:\n32", encoded)
+func (s *EncodedSwap) RequestHash() common.Hash {
+	encoded := common.BigToHash(s.raw)
+	if s.signNonTyped() {
+		return crypto.Keccak256Hash([]byte("\x19Ethereum Signed Message:\n32"), encoded[:])
+	}
+	return crypto.Keccak256Hash(requestTypeHash, crypto.Keccak256(encoded[:]))
+}
+
+// ReleaseHash 返回发起人授权在目标链上释放资金给recipient时需要签名的哈希
+// 类型化签名为 keccak256(typeHash, keccak256(encoded, recipient))，
+// 非类型化签名为 keccak256("\x19Ethereum Signed Message:\n52", encoded, recipient)
+func (s *EncodedSwap) ReleaseHash(recipient common.Address) common.Hash {
+	encoded := common.BigToHash(s.raw)
+	if s.signNonTyped() {
+		return crypto.Keccak256Hash([]byte("\x19Ethereum Signed Message:\n52"), encoded[:], recipient[:])
+	}
+	return crypto.Keccak256Hash(releaseTypeHash, crypto.Keccak256(encoded[:], recipient[:]))
+}
+
+// GetReleaseHash 返回跨链交易的release签名哈希，ref可以是swapId或encoded
+// 发起人用源链私钥签名后，可通过ReleaseSwap在目标链上自行释放资金
+func (b *Bridge) GetReleaseHash(ref, recipient string) (common.Hash, error) {
+	if err := b.validateAddresses(recipient); err != nil {
+		return common.Hash{}, err
+	}
+	swap, err := b.resolveEncodedSwap(ref)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return swap.ReleaseHash(common.HexToAddress(recipient)), nil
+}
@@ -0,0 +1,240 @@
+package meson
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrRecordNotFound 记录不存在
+var ErrRecordNotFound = errors.New("记录不存在")
+
+// TransferStatus 跨链转账在本地流程中所处的步骤
+type TransferStatus string
+
+const (
+	StatusCreated   TransferStatus = "created"   // 已创建，尚未授权
+	StatusApproved  TransferStatus = "approved"  // 授权交易已确认(或跳过授权)
+	StatusEncoded   TransferStatus = "encoded"   // 中继器已编码
+	StatusSigned    TransferStatus = "signed"    // 已签名，尚未提交
+	StatusSubmitted TransferStatus = "submitted" // 已提交到中继器，等待完成
+	StatusCompleted TransferStatus = "completed" // 目标链已释放
+	StatusCancelled TransferStatus = "cancelled" // 已过期取消，资金已退回
+	StatusExpired   TransferStatus = "expired"   // 提交前已过期，资金未离开钱包
+	StatusFailed    TransferStatus = "failed"    // 无法继续，需要人工处理
+)
+
+// Final 是否为终态
+func (s TransferStatus) Final() bool {
+	switch s {
+	case StatusCompleted, StatusCancelled, StatusExpired, StatusFailed:
+		return true
+	}
+	return false
+}
+
+// SwapRecord 一次跨链转账每个步骤的记录
+type SwapRecord struct {
+	ID          string         `json:"id"`
+	Status      TransferStatus `json:"status"`
+	FromChain   Chain          `json:"fromChain"`
+	ToChain     Chain          `json:"toChain"`
+	FromToken   Token          `json:"fromToken"`
+	ToToken     Token          `json:"toToken"`
+	Amount      string         `json:"amount"`
+	FromAddress string         `json:"fromAddress"`
+	Recipient   string         `json:"recipient"`
+	SkipApprove bool           `json:"skipApprove,omitempty"`
+	ExpireIn    int64          `json:"expireIn,omitempty"` // 交易有效期(秒)
+	FeeGuard    *FeeGuard      `json:"feeGuard,omitempty"`
+
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+
+	ApproveTxHash string    `json:"approveTxHash,omitempty"`
+	Encoded       string    `json:"encoded,omitempty"`
+	SigningHash   string    `json:"signingHash,omitempty"`
+	Fee           string    `json:"fee,omitempty"` // 编码时中继器给出的总手续费
+	Signature     string    `json:"signature,omitempty"`
+	SwapID        string    `json:"swapId,omitempty"`
+	Phase         SwapPhase `json:"phase,omitempty"` // 提交后中继器报告的最新阶段
+	CancelTxHash  string    `json:"cancelTxHash,omitempty"`
+	Error         string    `json:"error,omitempty"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SwapStore 跨链转账记录的持久化存储
+type SwapStore interface {
+	// Save 保存记录，已存在时覆盖
+	Save(record *SwapRecord) error
+	// Get 按ID获取记录，不存在时返回ErrRecordNotFound
+	Get(id string) (*SwapRecord, error)
+	// List 按创建时间返回所有记录
+	List() ([]*SwapRecord, error)
+	// Close 关闭存储
+	Close() error
+}
+
+// newRecordID 生成随机的记录ID
+func newRecordID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// recordIDForKey 由幂等键确定性地生成记录ID，相同的键总是对应同一条记录
+func recordIDForKey(key string) string {
+	sum := sha256.Sum256([]byte("idempotency:" + key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// MemoryStore 仅保存在内存中的存储，进程退出后丢失，Bridge默认使用
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records map[string]*SwapRecord
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*SwapRecord)}
+}
+
+func (s *MemoryStore) Save(record *SwapRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *record
+	s.records[record.ID] = &copied
+	return nil
+}
+
+func (s *MemoryStore) Get(id string) (*SwapRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok {
+		return nil, ErrRecordNotFound
+	}
+	copied := *record
+	return &copied, nil
+}
+
+func (s *MemoryStore) List() ([]*SwapRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]*SwapRecord, 0, len(s.records))
+	for _, record := range s.records {
+		copied := *record
+		records = append(records, &copied)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// FileStore 基于JSON-lines文件的存储，每次保存追加一行，读取时以最后一行为准
+type FileStore struct {
+	mem  *MemoryStore
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileStore 打开或创建JSON-lines存储文件，并加载已有记录，加载时跳过的内容记录到标准错误
+func NewFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("打开记录文件失败: %w", err)
+	}
+
+	mem := NewMemoryStore()
+	reader := bufio.NewReader(file)
+	var complete int64 // 最后一个完整行之后的偏移
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(data) > 0 {
+				// 进程崩溃可能留下写了一半的最后一行，截断后再追加，避免新记录接在这一行后面
+				fmt.Fprintf(os.Stderr, "丢弃记录文件第%d行不完整的内容\n", line)
+				if err := file.Truncate(complete); err != nil {
+					file.Close()
+					return nil, fmt.Errorf("截断记录文件失败: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("读取记录文件失败: %w", err)
+		}
+		complete += int64(len(data))
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		var record SwapRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			fmt.Fprintf(os.Stderr, "跳过记录文件第%d行: %v\n", line, err)
+			continue
+		}
+		mem.Save(&record)
+	}
+
+	return &FileStore{mem: mem, file: file}, nil
+}
+
+func (s *FileStore) Save(record *SwapRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化记录失败: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入记录失败: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("写入记录失败: %w", err)
+	}
+	return s.mem.Save(record)
+}
+
+func (s *FileStore) Get(id string) (*SwapRecord, error) {
+	return s.mem.Get(id)
+}
+
+func (s *FileStore) List() ([]*SwapRecord, error) {
+	return s.mem.List()
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// sortRecords 按创建时间排序
+func sortRecords(records []*SwapRecord) {
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].ID < records[j].ID
+		}
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+}
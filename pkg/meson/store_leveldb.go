@@ -0,0 +1,81 @@
+package meson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// swapRecordPrefix LevelDB中记录key的前缀
+var swapRecordPrefix = []byte("swap/")
+
+// LevelDBStore 基于嵌入式LevelDB的键值存储，适合记录较多的场景
+type LevelDBStore struct {
+	db *leveldb.DB
+}
+
+// NewLevelDBStore 打开或创建LevelDB存储目录
+func NewLevelDBStore(path string) (*LevelDBStore, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("打开LevelDB失败: %w", err)
+	}
+	return &LevelDBStore{db: db}, nil
+}
+
+func (s *LevelDBStore) Save(record *SwapRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("序列化记录失败: %w", err)
+	}
+	if err := s.db.Put(swapRecordKey(record.ID), data, nil); err != nil {
+		return fmt.Errorf("写入记录失败: %w", err)
+	}
+	return nil
+}
+
+func (s *LevelDBStore) Get(id string) (*SwapRecord, error) {
+	data, err := s.db.Get(swapRecordKey(id), nil)
+	if errors.Is(err, leveldb.ErrNotFound) {
+		return nil, ErrRecordNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取记录失败: %w", err)
+	}
+	var record SwapRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("解析记录失败: %w", err)
+	}
+	return &record, nil
+}
+
+func (s *LevelDBStore) List() ([]*SwapRecord, error) {
+	iter := s.db.NewIterator(util.BytesPrefix(swapRecordPrefix), nil)
+	defer iter.Release()
+
+	var records []*SwapRecord
+	for iter.Next() {
+		var record SwapRecord
+		if err := json.Unmarshal(iter.Value(), &record); err != nil {
+			return nil, fmt.Errorf("解析记录失败: %w", err)
+		}
+		records = append(records, &record)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, fmt.Errorf("遍历记录失败: %w", err)
+	}
+	sortRecords(records)
+	return records, nil
+}
+
+func (s *LevelDBStore) Close() error {
+	return s.db.Close()
+}
+
+// swapRecordKey 记录在LevelDB中的key
+func swapRecordKey(id string) []byte {
+	return append(append([]byte{}, swapRecordPrefix...), id...)
+}
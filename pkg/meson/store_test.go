@@ -0,0 +1,77 @@
+package meson
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "swaps.jsonl")
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	record := &SwapRecord{ID: newRecordID(), Status: StatusCreated, Amount: "1.5", CreatedAt: time.Now()}
+	require.NoError(t, store.Save(record))
+	record.Status = StatusSubmitted
+	record.SwapID = "0xabc"
+	require.NoError(t, store.Save(record))
+	require.NoError(t, store.Close())
+
+	// 模拟崩溃时写了一半的最后一行
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"id":"broken","sta`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	store, err = NewFileStore(path)
+	require.NoError(t, err)
+
+	loaded, err := store.Get(record.ID)
+	require.NoError(t, err)
+	require.Equal(t, StatusSubmitted, loaded.Status)
+	require.Equal(t, "0xabc", loaded.SwapID)
+
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	_, err = store.Get("missing")
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// 截断不完整的行后，新记录不会接在其后面
+	next := &SwapRecord{ID: newRecordID(), Status: StatusCreated, CreatedAt: time.Now()}
+	require.NoError(t, store.Save(next))
+	require.NoError(t, store.Close())
+
+	store, err = NewFileStore(path)
+	require.NoError(t, err)
+	defer store.Close()
+	loaded, err = store.Get(next.ID)
+	require.NoError(t, err)
+	require.Equal(t, StatusCreated, loaded.Status)
+	records, err = store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+}
+
+func TestLevelDBStore(t *testing.T) {
+	store, err := NewLevelDBStore(t.TempDir())
+	require.NoError(t, err)
+	defer store.Close()
+
+	record := &SwapRecord{ID: newRecordID(), Status: StatusEncoded, CreatedAt: time.Now()}
+	require.NoError(t, store.Save(record))
+
+	loaded, err := store.Get(record.ID)
+	require.NoError(t, err)
+	require.Equal(t, StatusEncoded, loaded.Status)
+
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+}
@@ -0,0 +1,522 @@
+package meson
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+)
+
+var (
+	// WatchInterval 等待跨链完成时查询中继器状态的间隔
+	WatchInterval = 10 * time.Second
+	// CancelGracePeriod 过期后等待多久仍未完成才发起取消
+	CancelGracePeriod = 5 * time.Minute
+)
+
+var (
+	// errSwapNotPosted 跨链交易在源链上不存在
+	errSwapNotPosted = errors.New("跨链交易不存在或已完成")
+	// ErrIdempotencyConflict 幂等键已被参数不同的转账使用
+	ErrIdempotencyConflict = errors.New("幂等键已被其他转账使用")
+	// ErrTransferFinal 转账已结束，不能再取消
+	ErrTransferFinal = errors.New("转账已结束")
+)
+
+// TransferRequest 跨链转账请求
+type TransferRequest struct {
+	FromChain   Chain
+	ToChain     Chain
+	FromToken   Token
+	ToToken     Token
+	Amount      TokenAmount
+	FromAddress string        // 发送地址，Transfer中为空时使用sender的地址
+	Recipient   string        // 接收地址，为空时与发送地址相同
+	SkipApprove bool          // 跳过授权步骤
+	ExpireIn    time.Duration // 交易有效期，为空时使用DefaultSwapExpiry
+	FeeGuard    *FeeGuard     // 手续费保护，编码结果超出范围时放弃该交易
+
+	// Prepared 用户确认过的跨链交易(PreviewTransfer的返回值)，设置后直接签名其中的encoded，不再重新编码
+	// 须与请求的链、代币、金额和地址一致；其中有预检问题时拒绝执行，除非设置了Force
+	Prepared *PreparedSwap
+	Force    bool // 忽略Prepared中预检发现的问题继续执行
+
+	// IdempotencyKey 幂等键，由调用方提供(如HTTP请求ID)
+	// 相同的键重复调用Transfer时返回已有的转账，不会重新编码和签名新的交易
+	IdempotencyKey string
+}
+
+// SetSwapStore 设置跨链转账记录的存储，默认仅保存在内存中
+func (b *Bridge) SetSwapStore(store SwapStore) {
+	b.store = store
+}
+
+// SwapStore 返回当前使用的记录存储
+func (b *Bridge) SwapStore() SwapStore {
+	return b.store
+}
+
+// Transfer 执行完整的跨链转账: 授权、编码、签名、提交，每一步完成后写入记录
+// 返回时交易已提交到中继器，可通过WatchTransfer等待完成；进程中断后可通过Resume继续
+func (b *Bridge) Transfer(ctx context.Context, sender *helpers.Sender, req *TransferRequest) (*SwapRecord, error) {
+	fromAddr := sender.From().Hex()
+	if req.FromAddress != "" && !sameAddress(req.FromAddress, sender.From()) {
+		return nil, fmt.Errorf("发送地址%s与签名账户%s不一致", req.FromAddress, fromAddr)
+	}
+	recipient := req.Recipient
+	if recipient == "" {
+		recipient = fromAddr
+	}
+	if req.Prepared != nil {
+		if err := checkPrepared(req, sender.From(), recipient); err != nil {
+			return nil, err
+		}
+	}
+
+	id := newRecordID()
+	if req.IdempotencyKey != "" {
+		id = recordIDForKey(req.IdempotencyKey)
+		defer b.keyLocks.lock(id)()
+
+		existing, err := b.store.Get(id)
+		switch {
+		case err == nil:
+			return b.resumeExisting(ctx, sender, existing, req, recipient)
+		case !errors.Is(err, ErrRecordNotFound):
+			return nil, fmt.Errorf("读取转账记录失败: %w", err)
+		}
+	}
+
+	now := time.Now()
+	record := &SwapRecord{
+		ID:             id,
+		IdempotencyKey: req.IdempotencyKey,
+		Status:         StatusCreated,
+		FromChain:      req.FromChain,
+		ToChain:        req.ToChain,
+		FromToken:      req.FromToken,
+		ToToken:        req.ToToken,
+		Amount:         req.Amount.String(),
+		FromAddress:    fromAddr,
+		Recipient:      recipient,
+		SkipApprove:    req.SkipApprove,
+		ExpireIn:       int64(req.ExpireIn / time.Second),
+		FeeGuard:       req.FeeGuard,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if prepared := req.Prepared; prepared != nil {
+		record.Encoded = prepared.Encoded
+		record.SigningHash = prepared.SigningHash
+		record.Fee = prepared.Price.TotalFee
+	}
+	if err := b.store.Save(record); err != nil {
+		return nil, fmt.Errorf("保存转账记录失败: %w", err)
+	}
+
+	if err := b.advance(ctx, sender, record); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+// checkPrepared 检查用户确认的跨链交易与请求一致、encoded与展示的字段相符、手续费在FeeGuard范围内，
+// 且预检没有发现问题(设置了Force时忽略)
+func checkPrepared(req *TransferRequest, from common.Address, recipient string) error {
+	prepared := req.Prepared
+	if prepared.FromChain != req.FromChain || prepared.ToChain != req.ToChain ||
+		prepared.FromToken != req.FromToken || prepared.ToToken != req.ToToken ||
+		!prepared.Amount.Decimal().Equal(req.Amount.Decimal()) ||
+		!sameAddress(prepared.FromAddress, from) ||
+		!strings.EqualFold(prepared.Recipient, recipient) {
+		return errors.New("确认的跨链交易与转账请求不一致")
+	}
+	if err := prepared.verifyEncoded(); err != nil {
+		return err
+	}
+	if err := problemsErr(prepared.Problems); err != nil && !req.Force {
+		return err
+	}
+	return req.FeeGuard.Check(req.Amount, &prepared.Price)
+}
+
+// keyLocker 按键加锁，最后一个持有者解锁时删除该键，长期运行时锁表不会无限增长
+type keyLocker struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock 单个键的锁及等待和持有它的数量
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock 锁定key，返回解锁函数
+func (l *keyLocker) lock(key string) func() {
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	entry, ok := l.locks[key]
+	if !ok {
+		entry = &keyLock{}
+		l.locks[key] = entry
+	}
+	entry.refs++
+	l.mu.Unlock()
+
+	entry.Lock()
+	return func() {
+		entry.Unlock()
+		l.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// resumeExisting 处理幂等键已存在的转账: 参数一致时返回已有记录，
+// 中途失败的转账从失败的步骤继续，不会重新编码新的交易
+func (b *Bridge) resumeExisting(ctx context.Context, sender *helpers.Sender, record *SwapRecord, req *TransferRequest, recipient string) (*SwapRecord, error) {
+	if record.FromChain != req.FromChain || record.ToChain != req.ToChain ||
+		record.FromToken != req.FromToken || record.ToToken != req.ToToken ||
+		record.Amount != req.Amount.String() ||
+		!sameAddress(record.FromAddress, sender.From()) ||
+		!strings.EqualFold(record.Recipient, recipient) {
+		return record, fmt.Errorf("%w: %s", ErrIdempotencyConflict, req.IdempotencyKey)
+	}
+
+	b.logf("幂等键%s对应的转账已存在: %s，当前状态: %s\n", req.IdempotencyKey, record.ID, record.Status)
+	if err := b.advance(ctx, sender, record); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+// FindTransfer 按幂等键查找转账记录，不存在时返回ErrRecordNotFound
+func (b *Bridge) FindTransfer(idempotencyKey string) (*SwapRecord, error) {
+	return b.store.Get(recordIDForKey(idempotencyKey))
+}
+
+// WatchTransfer 等待已提交的跨链转账完成，过期未完成时自动取消并退款
+func (b *Bridge) WatchTransfer(ctx context.Context, sender *helpers.Sender, id string) (*SwapRecord, error) {
+	record, err := b.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return record, b.watch(ctx, sender, record)
+}
+
+// CancelTransfer 取消转账: 尚未提交到中继器的转账直接标记为已取消，Resume不会再继续执行；
+// 已提交的转账只能在过期后取消，在源链上发送cancelSwap交易退款，未过期时返回ErrSwapNotExpired
+func (b *Bridge) CancelTransfer(ctx context.Context, sender *helpers.Sender, id string) (*SwapRecord, error) {
+	record, err := b.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status.Final() {
+		return record, fmt.Errorf("%w，当前状态: %s", ErrTransferFinal, record.Status)
+	}
+	if record.Status != StatusSubmitted {
+		return record, b.finish(record, StatusCancelled, nil)
+	}
+
+	result, err := b.CancelExpiredSwap(ctx, sender, record.FromChain, record.Encoded)
+	if err != nil {
+		return record, err
+	}
+	record.CancelTxHash = result.Tx.Hash.Hex()
+	b.advancePhase(record, PhaseCancelled)
+	return record, b.finish(record, StatusCancelled, nil)
+}
+
+// Resume 恢复所有未完成的跨链转账: 未提交的继续执行剩余步骤，已提交的等待完成或在过期后取消
+// 所有转账到达终态或ctx结束时返回
+func (b *Bridge) Resume(ctx context.Context, sender *helpers.Sender) ([]*SwapRecord, error) {
+	records, err := b.store.List()
+	if err != nil {
+		return nil, fmt.Errorf("读取转账记录失败: %w", err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		resumed []*SwapRecord
+		errs    []error
+	)
+	for _, record := range records {
+		if record.Status.Final() || !sameAddress(record.FromAddress, sender.From()) {
+			continue
+		}
+		resumed = append(resumed, record)
+		b.logf("恢复跨链转账 %s，当前状态: %s\n", record.ID, record.Status)
+
+		wg.Add(1)
+		go func(record *SwapRecord) {
+			defer wg.Done()
+			err := b.advance(ctx, sender, record)
+			if err == nil {
+				err = b.watch(ctx, sender, record)
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("转账%s: %w", record.ID, err))
+				mu.Unlock()
+			}
+		}(record)
+	}
+	wg.Wait()
+
+	return resumed, errors.Join(errs...)
+}
+
+// advance 从记录的当前步骤继续执行，直到提交到中继器
+func (b *Bridge) advance(ctx context.Context, sender *helpers.Sender, record *SwapRecord) error {
+	for {
+		var err error
+		switch record.Status {
+		case StatusCreated:
+			err = b.stepApprove(ctx, sender, record)
+		case StatusApproved:
+			err = b.stepEncode(ctx, record)
+		case StatusEncoded:
+			err = b.stepSign(sender, record)
+		case StatusSigned:
+			err = b.stepSubmit(record)
+		default:
+			return nil
+		}
+
+		if err != nil {
+			record.Error = err.Error()
+		} else {
+			record.Error = ""
+		}
+		record.UpdatedAt = time.Now()
+		if saveErr := b.store.Save(record); saveErr != nil {
+			return fmt.Errorf("保存转账记录失败: %w", saveErr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// stepApprove 授权额度不足转账金额时授权池合约使用代币，额度充足时直接进入已授权状态
+func (b *Bridge) stepApprove(ctx context.Context, sender *helpers.Sender, record *SwapRecord) error {
+	if !record.SkipApprove {
+		amount, err := ParseMesonAmount(record.Amount)
+		if err != nil {
+			return err
+		}
+		allowance, err := b.GetAllowance(ctx, record.FromChain, record.FromToken, record.FromAddress)
+		if err != nil {
+			return fmt.Errorf("查询授权额度失败: %w", err)
+		}
+		if allowance.Cmp(amount) >= 0 {
+			b.logf("授权额度%s已足够，无需授权\n", allowance)
+			record.Status = StatusApproved
+			return nil
+		}
+
+		txData, err := b.GetApproveData(ctx, record.FromAddress, record.FromChain, record.FromToken, "")
+		if err != nil {
+			return fmt.Errorf("获取Approve数据失败: %w", err)
+		}
+		result, err := sender.Send(ctx, txData)
+		if result != nil {
+			record.ApproveTxHash = result.Hash.Hex()
+		}
+		if err != nil {
+			return fmt.Errorf("发送Approve交易失败: %w", err)
+		}
+	}
+	record.Status = StatusApproved
+	return nil
+}
+
+// stepEncode 由中继器编码跨链交易，手续费超出FeeGuard范围时放弃该交易
+// 记录中已有用户确认过的encoded时直接使用
+func (b *Bridge) stepEncode(ctx context.Context, record *SwapRecord) error {
+	if record.Encoded != "" {
+		record.Status = StatusEncoded
+		return nil
+	}
+	amount, err := ParseMesonAmount(record.Amount)
+	if err != nil {
+		return err
+	}
+	prepared, err := b.PrepareSwap(ctx, &TransferRequest{
+		FromChain:   record.FromChain,
+		ToChain:     record.ToChain,
+		FromToken:   record.FromToken,
+		ToToken:     record.ToToken,
+		Amount:      amount,
+		FromAddress: record.FromAddress,
+		Recipient:   record.Recipient,
+		ExpireIn:    time.Duration(record.ExpireIn) * time.Second,
+	}, record.FeeGuard)
+	if errors.Is(err, ErrFeeExceeded) {
+		record.Status = StatusFailed
+	}
+	if err != nil {
+		return err
+	}
+	record.Encoded = prepared.Encoded
+	record.SigningHash = prepared.SigningHash
+	record.Fee = prepared.Price.TotalFee
+	record.Status = StatusEncoded
+	return nil
+}
+
+// stepSign 签名跨链交易
+func (b *Bridge) stepSign(sender *helpers.Sender, record *SwapRecord) error {
+	if expired(record) {
+		record.Status = StatusExpired
+		return nil
+	}
+	signature, err := sender.SignHash(common.FromHex(record.SigningHash))
+	if err != nil {
+		return fmt.Errorf("签名失败: %w", err)
+	}
+	record.Signature = "0x" + common.Bytes2Hex(signature)
+	record.Status = StatusSigned
+	return nil
+}
+
+// stepSubmit 提交跨链交易到中继器
+func (b *Bridge) stepSubmit(record *SwapRecord) error {
+	if expired(record) {
+		record.Status = StatusExpired
+		return nil
+	}
+	swapId, err := b.SubmitSwap(record.Encoded, record.FromAddress, record.Recipient, common.FromHex(record.Signature))
+	if err != nil {
+		return err
+	}
+	record.SwapID = swapId
+	record.Status = StatusSubmitted
+	return nil
+}
+
+// watch 轮询中继器状态直到跨链完成，过期超过CancelGracePeriod仍未完成时取消
+func (b *Bridge) watch(ctx context.Context, sender *helpers.Sender, record *SwapRecord) error {
+	if record.Status != StatusSubmitted {
+		return nil
+	}
+	swap, err := DecodeEncodedSwap(record.Encoded)
+	if err != nil {
+		return err
+	}
+
+	for {
+		status, err := b.client.GetSwapStatus(record.SwapID)
+		if err != nil {
+			b.logf("查询跨链状态失败: %v\n", err)
+		} else {
+			b.advancePhase(record, RelayerPhase(status))
+			claims := relayerClaims(status)
+			switch {
+			case claims[PhaseReleased] || claims[PhaseExecuted]:
+				return b.finish(record, StatusCompleted, nil)
+			case claims[PhaseCancelled]:
+				return b.finish(record, StatusCancelled, nil)
+			}
+		}
+
+		if time.Now().After(swap.ExpireTime().Add(CancelGracePeriod)) {
+			pool, err := b.pool(record.FromChain)
+			if err != nil {
+				return err
+			}
+			result, err := b.cancelSwap(ctx, sender, pool, swap)
+			switch {
+			case err == nil:
+				record.CancelTxHash = result.Tx.Hash.Hex()
+				b.advancePhase(record, PhaseCancelled)
+				return b.finish(record, StatusCancelled, nil)
+			case errors.Is(err, errSwapNotPosted):
+				return b.finish(record, StatusFailed, fmt.Errorf("跨链交易已过期但源链上不存在，请人工核对: %w", err))
+			default:
+				b.logf("取消过期交易失败，稍后重试: %v\n", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(WatchInterval):
+		}
+	}
+}
+
+// PhaseChange 已提交的跨链转账进入新的阶段
+type PhaseChange struct {
+	Record   *SwapRecord // 更新后的转账记录(副本)
+	Previous SwapPhase   // 之前的阶段，首次报告时为PhaseUnknown
+	Phase    SwapPhase
+	Time     time.Time
+}
+
+// OnPhaseChange 注册阶段变化的回调，在等待转账完成(WatchTransfer、Resume)时按阶段先后调用
+// 回调在等待转账的goroutine中同步执行，耗时的处理应放到其他goroutine
+func (b *Bridge) OnPhaseChange(fn func(*PhaseChange)) {
+	b.listenersMu.Lock()
+	defer b.listenersMu.Unlock()
+	b.listeners = append(b.listeners, fn)
+}
+
+// advancePhase 阶段向前推进时更新记录并通知回调，重复或倒退的阶段忽略
+func (b *Bridge) advancePhase(record *SwapRecord, phase SwapPhase) {
+	if phase.rank() <= record.Phase.rank() {
+		return
+	}
+	previous, now := record.Phase, time.Now()
+	record.Phase = phase
+	record.UpdatedAt = now
+	if err := b.store.Save(record); err != nil {
+		b.logf("保存转账记录失败: %v\n", err)
+	}
+
+	b.listenersMu.RLock()
+	listeners := b.listeners
+	b.listenersMu.RUnlock()
+	for _, fn := range listeners {
+		copied := *record
+		fn(&PhaseChange{Record: &copied, Previous: previous, Phase: phase, Time: now})
+	}
+}
+
+// finish 将记录更新为终态
+func (b *Bridge) finish(record *SwapRecord, status TransferStatus, cause error) error {
+	record.Status = status
+	record.Error = ""
+	if cause != nil {
+		record.Error = cause.Error()
+	}
+	record.UpdatedAt = time.Now()
+	if err := b.store.Save(record); err != nil {
+		return fmt.Errorf("保存转账记录失败: %w", err)
+	}
+	return cause
+}
+
+// expired 已编码的交易是否已过期
+func expired(record *SwapRecord) bool {
+	swap, err := DecodeEncodedSwap(record.Encoded)
+	return err == nil && time.Now().After(swap.ExpireTime())
+}
+
+// sameAddress 比较地址(不区分大小写)
+func sameAddress(addr string, other common.Address) bool {
+	return common.IsHexAddress(addr) && common.HexToAddress(addr) == other
+}
@@ -0,0 +1,254 @@
+package meson
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+)
+
+func TestTransfer_IdempotencyKey(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := helpers.NewSender(nil, big.NewInt(4200), key)
+
+	bridge := NewBridge()
+	req := &TransferRequest{
+		FromChain:      ChainMerlin,
+		ToChain:        "bnb",
+		FromToken:      TokenMERL,
+		ToToken:        TokenMERL,
+		Amount:         mustTokenAmount(t, "10", MesonDecimals),
+		IdempotencyKey: "order-1",
+	}
+
+	// 已提交的转账直接返回，不会重新执行任何步骤
+	existing := &SwapRecord{
+		ID:             recordIDForKey(req.IdempotencyKey),
+		IdempotencyKey: req.IdempotencyKey,
+		Status:         StatusSubmitted,
+		FromChain:      req.FromChain,
+		ToChain:        req.ToChain,
+		FromToken:      req.FromToken,
+		ToToken:        req.ToToken,
+		Amount:         "10",
+		FromAddress:    sender.From().Hex(),
+		Recipient:      sender.From().Hex(),
+		SwapID:         "0xswap",
+	}
+	require.NoError(t, bridge.SwapStore().Save(existing))
+
+	record, err := bridge.Transfer(context.Background(), sender, req)
+	require.NoError(t, err)
+	require.Equal(t, existing.ID, record.ID)
+	require.Equal(t, "0xswap", record.SwapID)
+
+	// 相同的键但参数不同
+	req.Amount = mustTokenAmount(t, "20", MesonDecimals)
+	_, err = bridge.Transfer(context.Background(), sender, req)
+	require.ErrorIs(t, err, ErrIdempotencyConflict)
+
+	records, err := bridge.SwapStore().List()
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+}
+
+func TestTransfer_PhaseChange(t *testing.T) {
+	bridge := NewBridge()
+	var changes []*PhaseChange
+	bridge.OnPhaseChange(func(change *PhaseChange) {
+		changes = append(changes, change)
+	})
+
+	record := &SwapRecord{ID: "r1", Status: StatusSubmitted}
+	require.NoError(t, bridge.SwapStore().Save(record))
+	for _, phase := range []SwapPhase{PhasePosted, PhasePosted, PhaseLocked, PhaseBonded, PhaseExecuted} {
+		bridge.advancePhase(record, phase)
+	}
+
+	// 重复和倒退的阶段不通知
+	require.Len(t, changes, 3)
+	require.Equal(t, PhaseUnknown, changes[0].Previous)
+	require.Equal(t, PhaseLocked, changes[1].Phase)
+	require.Equal(t, PhasePosted, changes[1].Previous)
+	require.Equal(t, PhaseLocked, changes[1].Record.Phase) // 记录为副本
+	require.Equal(t, PhaseExecuted, changes[2].Phase)
+
+	saved, err := bridge.SwapStore().Get("r1")
+	require.NoError(t, err)
+	require.Equal(t, PhaseExecuted, saved.Phase)
+}
+
+// fakeTokenNode 进程内的模拟节点，只响应ERC20的decimals和allowance查询
+type fakeTokenNode struct {
+	allowance *big.Int
+}
+
+func (f *fakeTokenNode) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	input, _ := args["input"].(string)
+	if input == "" {
+		input, _ = args["data"].(string)
+	}
+	data := common.FromHex(input)
+	switch hexutil.Encode(data[:4]) {
+	case "0x313ce567": // decimals()
+		return common.LeftPadBytes(big.NewInt(18).Bytes(), 32), nil
+	case "0xdd62ed3e": // allowance(address,address)
+		return common.LeftPadBytes(f.allowance.Bytes(), 32), nil
+	}
+	return nil, nil
+}
+
+// connectFakeToken 将模拟节点连接为链上的客户端
+func connectFakeToken(t *testing.T, bridge *Bridge, chain Chain, node *fakeTokenNode) {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("eth", node))
+	client := rpc.DialInProc(server)
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+	bridge.ethClients[chain] = ethclient.NewClient(client)
+	require.NoError(t, bridge.RegisterTokenAddress(chain, TokenMERL, MERLAddress))
+	require.NoError(t, bridge.RegisterPoolAddress(chain, PoolAddress))
+}
+
+func TestStepApprove_SkipsWhenAllowanceSuffices(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := helpers.NewSender(nil, big.NewInt(4200), key)
+	node := &fakeTokenNode{}
+	bridge := NewBridge()
+	connectFakeToken(t, bridge, ChainMerlin, node)
+	newRecord := func() *SwapRecord {
+		return &SwapRecord{Status: StatusCreated, FromChain: ChainMerlin, FromToken: TokenMERL, Amount: "10", FromAddress: sender.From().Hex()}
+	}
+
+	// 额度足够时不发送授权交易
+	node.allowance, _ = new(big.Int).SetString("10000000000000000000", 10)
+	record := newRecord()
+	require.NoError(t, bridge.stepApprove(context.Background(), sender, record))
+	require.Equal(t, StatusApproved, record.Status)
+	require.Empty(t, record.ApproveTxHash)
+
+	// 额度不足时需要授权，未初始化当前链时生成授权数据失败
+	node.allowance, _ = new(big.Int).SetString("9999999999999999999", 10)
+	record = newRecord()
+	err = bridge.stepApprove(context.Background(), sender, record)
+	require.ErrorContains(t, err, "获取Approve数据失败")
+	require.Equal(t, StatusCreated, record.Status)
+}
+
+func TestKeyLocker(t *testing.T) {
+	var (
+		locker  keyLocker
+		wg      sync.WaitGroup
+		holders atomic.Int32
+		overlap atomic.Bool
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := "order-1"
+			if i%2 == 1 {
+				key = "order-2"
+			}
+			unlock := locker.lock(key)
+			defer unlock()
+			if key == "order-1" {
+				// 同一键同时只有一个持有者
+				if holders.Add(1) > 1 {
+					overlap.Store(true)
+				}
+				defer holders.Add(-1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.False(t, overlap.Load())
+
+	// 最后一个持有者解锁后删除该键
+	require.Empty(t, locker.locks)
+}
+
+func TestTransfer_Prepared(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := helpers.NewSender(nil, big.NewInt(4200), key)
+
+	// 中继器只接受提交，重新编码即为错误
+	var submitted string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/swap" {
+			t.Errorf("确认过的交易不应重新编码")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		submitted = strings.TrimPrefix(r.URL.Path, "/swap/")
+		w.Write([]byte(`{"result": {"swapId": "0xswap"}}`))
+	}))
+	defer srv.Close()
+	bridge := NewBridge()
+	bridge.client.baseURL = srv.URL
+	bridge.SetLogOutput(io.Discard)
+
+	newRequest := func() *TransferRequest {
+		prepared := newOfflineRequest(t, sender.From().Hex()).Swap
+		prepared.FromToken, prepared.ToToken = TokenMERL, TokenMERL
+		prepared.Price.TotalFee = "0.1"
+		return &TransferRequest{
+			FromChain:   ChainMerlin,
+			ToChain:     ChainZksync,
+			FromToken:   TokenMERL,
+			ToToken:     TokenMERL,
+			Amount:      mustTokenAmount(t, "6", MesonDecimals),
+			SkipApprove: true,
+			Prepared:    prepared,
+		}
+	}
+
+	// 与请求不一致或encoded被篡改时拒绝
+	req := newRequest()
+	req.Amount = mustTokenAmount(t, "7", MesonDecimals)
+	_, err = bridge.Transfer(context.Background(), sender, req)
+	require.ErrorContains(t, err, "不一致")
+	req = newRequest()
+	req.Prepared.SigningHash = common.Hash{}.Hex()
+	_, err = bridge.Transfer(context.Background(), sender, req)
+	require.ErrorContains(t, err, "签名哈希")
+
+	// 有预检问题时拒绝，设置Force后继续
+	req = newRequest()
+	req.Prepared.Problems = []PreflightProblem{{Check: CheckBalance, Message: "代币余额不足"}}
+	_, err = bridge.Transfer(context.Background(), sender, req)
+	require.ErrorIs(t, err, ErrPreflightFailed)
+	records, err := bridge.SwapStore().List()
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	req.Force = true
+	record, err := bridge.Transfer(context.Background(), sender, req)
+	require.NoError(t, err)
+	require.Equal(t, StatusSubmitted, record.Status)
+	require.Equal(t, req.Prepared.Encoded, record.Encoded)
+	require.Equal(t, req.Prepared.Encoded, submitted)
+	require.Equal(t, "0.1", record.Fee)
+	signature, err := sender.SignHash(common.FromHex(req.Prepared.SigningHash))
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Encode(signature), record.Signature)
+}
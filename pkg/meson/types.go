@@ -54,3 +54,23 @@ type SwapStatus struct {
 	ToTx      string `json:"toTx"`
 	Timestamp int64  `json:"timestamp"`
 }
+
+// ChainInfo 中继器支持的链
+type ChainInfo struct {
+	ID      string      `json:"id"`
+	Name    string      `json:"name"`
+	ChainID string      `json:"chainId"`
+	Address string      `json:"address"` // 池合约地址
+	Tokens  []TokenInfo `json:"tokens"`
+}
+
+// TokenInfo 中继器支持的代币及单笔金额限制
+type TokenInfo struct {
+	ID         string `json:"id"`
+	Symbol     string `json:"symbol"`
+	Addr       string `json:"addr"`
+	Decimals   int    `json:"decimals"`
+	TokenIndex int    `json:"tokenIndex"`
+	Min        string `json:"min"` // 单笔最小金额，为空表示不限制
+	Max        string `json:"max"` // 单笔最大金额，为空表示不限制
+}
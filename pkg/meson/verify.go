@@ -0,0 +1,198 @@
+package meson
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// verifyLookback 查询事件时在过期时间之前回溯的时长(秒)，覆盖交易从提交到过期的整个周期
+const verifyLookback = 2 * 24 * 3600
+
+// SwapVerification 跨链交易的链上核验结果
+type SwapVerification struct {
+	SwapID    string
+	Encoded   string
+	Initiator common.Address
+	FromChain Chain
+	ToChain   Chain
+
+	RelayerPhase  SwapPhase   // 中继器声称的最新阶段
+	OnChainPhase  SwapPhase   // 链上事件证明的最新阶段
+	OnChainEvents []SwapEvent // 源链和目标链上该交易的所有事件
+
+	Posted *PostedSwap // 源链当前的提交状态
+	Locked *LockedSwap // 目标链当前的锁定状态
+
+	Settled       bool     // 目标链上已释放给接收方
+	Discrepancies []string // 中继器状态与链上状态的不一致之处
+}
+
+// Consistent 中继器状态与链上状态是否一致
+func (v *SwapVerification) Consistent() bool {
+	return len(v.Discrepancies) == 0
+}
+
+// VerifySwap 独立核验中继器返回的跨链交易状态
+// 查询源链的提交状态和目标链的锁定状态，并对照两条链上的事件，报告与中继器声称状态的差异
+// 需要通过InitEthClient/AddChainClient连接源链和目标链并注册池地址
+func (b *Bridge) VerifySwap(ctx context.Context, swapId string) (*SwapVerification, error) {
+	status, err := b.client.GetSwapStatus(swapId)
+	if err != nil {
+		return nil, fmt.Errorf("查询中继器状态失败: %w", err)
+	}
+	encoded, _ := status["encoded"].(string)
+	swap, err := DecodeEncodedSwap(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("中继器返回的encoded无效: %w", err)
+	}
+
+	claims := relayerClaims(status)
+	v := &SwapVerification{
+		SwapID:       swapId,
+		Encoded:      swap.Hex(),
+		RelayerPhase: RelayerPhase(status),
+	}
+	if v.FromChain, err = b.chainByCode(swap.InChain); err != nil {
+		return nil, err
+	}
+	if v.ToChain, err = b.chainByCode(swap.OutChain); err != nil {
+		return nil, err
+	}
+
+	// 源链: 提交状态和事件
+	srcPool, err := b.pool(v.FromChain)
+	if err != nil {
+		return nil, err
+	}
+	if v.Posted, err = srcPool.GetPostedSwap(ctx, swap.Int()); err != nil {
+		return nil, fmt.Errorf("查询源链提交状态失败: %w", err)
+	}
+	srcEvents, err := b.swapEvents(ctx, v.FromChain, srcPool, swap)
+	if err != nil {
+		return nil, err
+	}
+
+	// 发起人优先使用中继器返回的，交易已完成时源链上不再有记录
+	if initiator, ok := status["initiator"].(string); ok && common.IsHexAddress(initiator) {
+		v.Initiator = common.HexToAddress(initiator)
+	} else if v.Posted.Exist {
+		v.Initiator = v.Posted.Initiator
+	} else {
+		return nil, fmt.Errorf("无法确定跨链交易的发起人: %s", swapId)
+	}
+
+	// 目标链: 锁定状态和事件
+	dstPool, err := b.pool(v.ToChain)
+	if err != nil {
+		return nil, err
+	}
+	if v.Locked, err = dstPool.GetLockedSwap(ctx, swap.Int(), v.Initiator); err != nil {
+		return nil, fmt.Errorf("查询目标链锁定状态失败: %w", err)
+	}
+	dstEvents, err := b.swapEvents(ctx, v.ToChain, dstPool, swap)
+	if err != nil {
+		return nil, err
+	}
+
+	v.OnChainEvents = append(srcEvents, dstEvents...)
+	observed := make(map[SwapPhase]bool)
+	for _, event := range v.OnChainEvents {
+		observed[event.Phase] = true
+		if event.Phase.rank() > v.OnChainPhase.rank() {
+			v.OnChainPhase = event.Phase
+		}
+	}
+	v.Settled = observed[PhaseReleased]
+	v.Discrepancies = discrepancies(claims, observed, v.Posted, v.Locked)
+
+	return v, nil
+}
+
+// discrepancies 逐个阶段对照中继器声称的状态与链上证据，以及两条链上的当前状态与事件是否矛盾
+func discrepancies(claims, observed map[SwapPhase]bool, posted *PostedSwap, locked *LockedSwap) []string {
+	var found []string
+	for _, phase := range []SwapPhase{PhasePosted, PhaseBonded, PhaseLocked, PhaseReleased, PhaseExecuted, PhaseCancelled} {
+		claimed := claims[phase]
+		switch {
+		case claimed && !observed[phase]:
+			found = append(found, fmt.Sprintf("中继器声称%s，但链上未找到对应事件", phase))
+		case !claimed && observed[phase]:
+			found = append(found, fmt.Sprintf("链上已%s，但中继器未报告", phase))
+		}
+	}
+	if posted.Exist && (observed[PhaseExecuted] || observed[PhaseCancelled]) {
+		found = append(found, "源链交易已执行或取消，但仍处于提交状态")
+	}
+	if locked.Locked() && observed[PhaseReleased] {
+		found = append(found, "目标链已释放，但仍处于锁定状态")
+	}
+	return found
+}
+
+// swapEvents 查询某条链上该跨链交易的事件，从过期前verifyLookback开始查询到最新区块
+func (b *Bridge) swapEvents(ctx context.Context, chain Chain, pool *MesonPool, swap *EncodedSwap) ([]SwapEvent, error) {
+	head, err := pool.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s链最新区块失败: %w", chain, err)
+	}
+	start := uint64(0)
+	if swap.ExpireTs > verifyLookback {
+		start = uint64(swap.ExpireTs - verifyLookback)
+	}
+	fromBlock, err := blockAtTime(ctx, pool.client, start, head.Number.Uint64())
+	if err != nil {
+		return nil, fmt.Errorf("定位%s链起始区块失败: %w", chain, err)
+	}
+	events, err := pool.FilterSwapEventsFor(ctx, chain, swap.Int(), fromBlock, head.Number.Uint64(), DefaultLogChunkSize)
+	if err != nil {
+		return nil, fmt.Errorf("查询%s链事件失败: %w", chain, err)
+	}
+	return events, nil
+}
+
+// blockAtTime 二分查找时间戳不早于ts的第一个区块
+func blockAtTime(ctx context.Context, client *ethclient.Client, ts, head uint64) (uint64, error) {
+	low, high := uint64(0), head
+	for low < high {
+		mid := low + (high-low)/2
+		header, err := client.HeaderByNumber(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return 0, err
+		}
+		if header.Time < ts {
+			low = mid + 1
+		} else {
+			high = mid
+		}
+	}
+	return low, nil
+}
+
+// RelayerPhase 返回中继器状态中最新的阶段，没有已知阶段时返回PhaseUnknown
+func RelayerPhase(status map[string]any) SwapPhase {
+	latest := PhaseUnknown
+	for phase := range relayerClaims(status) {
+		if phase.rank() > latest.rank() {
+			latest = phase
+		}
+	}
+	return latest
+}
+
+// relayerClaims 从中继器状态中取出声称已完成的阶段
+// 中继器以阶段名为key返回各阶段的信息，如 {"POSTED": {...}, "RELEASED": {...}}
+func relayerClaims(status map[string]any) map[SwapPhase]bool {
+	claims := make(map[SwapPhase]bool)
+	for key, value := range status {
+		phase := SwapPhase(strings.ToUpper(key))
+		if value != nil && phase.rank() > 0 {
+			claims[phase] = true
+		}
+	}
+	return claims
+}
@@ -0,0 +1,107 @@
+package meson
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayerPhase(t *testing.T) {
+	tests := []struct {
+		name   string
+		status map[string]any
+		claims []SwapPhase
+		phase  SwapPhase
+	}{
+		{"empty", map[string]any{}, nil, PhaseUnknown},
+		{"posted", map[string]any{"POSTED": map[string]any{"hash": "0x1"}}, []SwapPhase{PhasePosted}, PhasePosted},
+		{"lowercase", map[string]any{"bonded": true, "posted": true}, []SwapPhase{PhasePosted, PhaseBonded}, PhaseBonded},
+		{"null ignored", map[string]any{"POSTED": true, "RELEASED": nil}, []SwapPhase{PhasePosted}, PhasePosted},
+		{"unknown keys ignored", map[string]any{"encoded": "0x01", "expired": true, "LOCKED": true}, []SwapPhase{PhaseLocked}, PhaseLocked},
+		{"executed", map[string]any{"POSTED": true, "LOCKED": true, "RELEASED": true, "EXECUTED": true}, []SwapPhase{PhasePosted, PhaseLocked, PhaseReleased, PhaseExecuted}, PhaseExecuted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := relayerClaims(tt.status)
+			require.Len(t, claims, len(tt.claims))
+			for _, phase := range tt.claims {
+				require.True(t, claims[phase], phase)
+			}
+			require.Equal(t, tt.phase, RelayerPhase(tt.status))
+		})
+	}
+}
+
+func TestDiscrepancies(t *testing.T) {
+	lp := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	phases := func(list ...SwapPhase) map[SwapPhase]bool {
+		m := make(map[SwapPhase]bool)
+		for _, phase := range list {
+			m[phase] = true
+		}
+		return m
+	}
+
+	tests := []struct {
+		name     string
+		claims   map[SwapPhase]bool
+		observed map[SwapPhase]bool
+		posted   *PostedSwap
+		locked   *LockedSwap
+		want     []string
+	}{
+		{
+			name:     "consistent",
+			claims:   phases(PhasePosted, PhaseLocked, PhaseReleased),
+			observed: phases(PhasePosted, PhaseLocked, PhaseReleased),
+			posted:   &PostedSwap{Exist: true},
+			locked:   &LockedSwap{},
+		},
+		{
+			name:     "relayer claims release without event",
+			claims:   phases(PhasePosted, PhaseReleased),
+			observed: phases(PhasePosted),
+			posted:   &PostedSwap{Exist: true},
+			locked:   &LockedSwap{},
+			want:     []string{"中继器声称RELEASED，但链上未找到对应事件"},
+		},
+		{
+			name:     "relayer missed cancel",
+			claims:   phases(PhasePosted),
+			observed: phases(PhasePosted, PhaseCancelled),
+			posted:   &PostedSwap{},
+			locked:   &LockedSwap{},
+			want:     []string{"链上已CANCELLED，但中继器未报告"},
+		},
+		{
+			name:     "state contradicts events",
+			claims:   phases(PhasePosted, PhaseLocked, PhaseReleased, PhaseExecuted),
+			observed: phases(PhasePosted, PhaseLocked, PhaseReleased, PhaseExecuted),
+			posted:   &PostedSwap{Exist: true},
+			locked:   &LockedSwap{PoolOwner: lp},
+			want:     []string{"源链交易已执行或取消，但仍处于提交状态", "目标链已释放，但仍处于锁定状态"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, discrepancies(tt.claims, tt.observed, tt.posted, tt.locked))
+		})
+	}
+}
+
+func TestChainByCode(t *testing.T) {
+	b := NewBridge()
+	for code, chain := range map[uint16]Chain{0x003c: "eth", 0x1068: ChainMerlin, 0x0324: ChainZksync} {
+		got, err := b.chainByCode(code)
+		require.NoError(t, err)
+		require.Equal(t, chain, got)
+	}
+
+	_, err := b.chainByCode(0x1234)
+	require.Error(t, err)
+	b.RegisterChainCode("custom", 0x1234)
+	got, err := b.chainByCode(0x1234)
+	require.NoError(t, err)
+	require.Equal(t, Chain("custom"), got)
+}
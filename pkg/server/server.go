@@ -0,0 +1,491 @@
+// Package server 提供跨链服务的REST API，集中管理签名私钥和跨链转账
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+// maxBodySize 请求体的最大字节数
+const maxBodySize = 1 << 20
+
+// Config 服务配置
+type Config struct {
+	Tokens     []string                          // 允许访问的bearer token
+	ParseToken func(string) (meson.Token, error) // 解析请求中的代币名称或ID，为nil时原样使用
+	Log        io.Writer                         // 后台任务的日志输出，为nil时写入标准错误
+}
+
+// Server 跨链服务，所有转账由同一个签名账户从源链发起
+//
+//	POST /v1/quote                  询价
+//	POST /v1/transfers              发起转账，提交到中继器后返回，之后在后台等待完成
+//	GET  /v1/transfers              列出转账，可用status和limit参数过滤
+//	GET  /v1/transfers/{id}         查询转账
+//	POST /v1/transfers/{id}/cancel  取消转账
+//	GET  /healthz                   健康检查，不需要认证
+type Server struct {
+	bridge *meson.Bridge
+	sender *helpers.Sender
+	chain  meson.Chain
+	config Config
+
+	ctx context.Context // 后台任务的上下文，服务停止时取消
+	wg  sync.WaitGroup
+
+	mu      sync.Mutex
+	watches map[string]*watcher // 后台等待中的转账
+}
+
+// watcher 后台等待转账的goroutine
+type watcher struct {
+	cancel context.CancelFunc
+	done   chan struct{} // goroutine退出后关闭
+}
+
+// New 创建服务，ctx结束时停止后台任务
+func New(ctx context.Context, bridge *meson.Bridge, sender *helpers.Sender, chain meson.Chain, config Config) *Server {
+	return &Server{
+		bridge:  bridge,
+		sender:  sender,
+		chain:   chain,
+		config:  config,
+		ctx:     ctx,
+		watches: make(map[string]*watcher),
+	}
+}
+
+// Resume 在后台继续执行记录中该签名账户所有未完成的转账
+func (s *Server) Resume() error {
+	records, err := s.bridge.SwapStore().List()
+	if err != nil {
+		return fmt.Errorf("读取转账记录失败: %w", err)
+	}
+	for _, record := range records {
+		if record.Status.Final() || !strings.EqualFold(record.FromAddress, s.sender.From().Hex()) {
+			continue
+		}
+		// 未提交且没有幂等键的转账无法通过Transfer继续执行
+		if record.Status == meson.StatusSubmitted || record.IdempotencyKey != "" {
+			s.watch(record.ID, record.IdempotencyKey)
+		}
+	}
+	return nil
+}
+
+// Wait 等待后台任务结束
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+// Handler 返回HTTP处理器
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(s.route)
+}
+
+// route 按路径分发请求
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if path == "/healthz" {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeError(w, http.StatusUnauthorized, errors.New("未授权"))
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/v1/"), "/")
+	if !strings.HasPrefix(path, "/v1/") {
+		parts = nil
+	}
+	switch {
+	case len(parts) == 1 && parts[0] == "quote":
+		s.only(w, r, http.MethodPost, s.handleQuote)
+	case len(parts) == 1 && parts[0] == "transfers":
+		if r.Method == http.MethodGet {
+			s.handleList(w, r)
+		} else {
+			s.only(w, r, http.MethodPost, s.handleCreate)
+		}
+	case len(parts) == 2 && parts[0] == "transfers":
+		s.only(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) { s.handleGet(w, parts[1]) })
+	case len(parts) == 3 && parts[0] == "transfers" && parts[2] == "cancel":
+		s.only(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) { s.handleCancel(w, r, parts[1]) })
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("未知的路径: %s", r.URL.Path))
+	}
+}
+
+// only 只允许指定的请求方法
+func (s *Server) only(w http.ResponseWriter, r *http.Request, method string, handler http.HandlerFunc) {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("不支持的请求方法: %s", r.Method))
+		return
+	}
+	handler(w, r)
+}
+
+// authorized 检查Authorization头中的bearer token
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	for _, allowed := range s.config.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteRequest 询价请求，源链为服务的签名链
+type quoteRequest struct {
+	ToChain     string `json:"toChain"`
+	FromToken   string `json:"fromToken"`
+	ToToken     string `json:"toToken,omitempty"` // 默认与源链代币相同
+	Amount      string `json:"amount"`
+	FromAddress string `json:"fromAddress,omitempty"` // 默认为签名账户地址
+}
+
+// quoteResponse 询价结果
+type quoteResponse struct {
+	FromChain  meson.Chain `json:"fromChain"`
+	ToChain    meson.Chain `json:"toChain"`
+	FromToken  meson.Token `json:"fromToken"`
+	ToToken    meson.Token `json:"toToken"`
+	Amount     string      `json:"amount"`
+	ServiceFee string      `json:"serviceFee"`
+	LpFee      string      `json:"lpFee"`
+	TotalFee   string      `json:"totalFee"`
+	Received   string      `json:"received,omitempty"`
+}
+
+// handleQuote 询价
+func (s *Server) handleQuote(w http.ResponseWriter, r *http.Request) {
+	req := &quoteRequest{}
+	if err := readJSON(w, r, req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	fromToken, toToken, err := s.parseTokens(req.FromToken, req.ToToken)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	amount, err := meson.ParseMesonAmount(req.Amount)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ToChain == "" {
+		writeError(w, http.StatusBadRequest, errors.New("缺少目标链"))
+		return
+	}
+	if req.FromAddress == "" {
+		req.FromAddress = s.sender.From().Hex()
+	}
+
+	price, err := s.bridge.GetPrice(amount, req.FromAddress, s.chain, meson.Chain(req.ToChain), fromToken, toToken)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	resp := &quoteResponse{
+		FromChain:  s.chain,
+		ToChain:    meson.Chain(req.ToChain),
+		FromToken:  fromToken,
+		ToToken:    toToken,
+		Amount:     amount.String(),
+		ServiceFee: price.ServiceFee,
+		LpFee:      price.LpFee,
+		TotalFee:   price.TotalFee,
+	}
+	if totalFee, err := decimal.NewFromString(price.TotalFee); err == nil {
+		resp.Received = amount.Decimal().Sub(totalFee).String()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// transferRequest 发起转账的请求
+type transferRequest struct {
+	ToChain        string          `json:"toChain"`
+	FromToken      string          `json:"fromToken"`
+	ToToken        string          `json:"toToken,omitempty"` // 默认与源链代币相同
+	Amount         string          `json:"amount"`
+	Recipient      string          `json:"recipient,omitempty"` // 默认为签名账户地址
+	SkipApprove    bool            `json:"skipApprove,omitempty"`
+	ExpireIn       int64           `json:"expireIn,omitempty"`       // 有效期(秒)，默认meson.DefaultSwapExpiry
+	MaxFee         decimal.Decimal `json:"maxFee"`                   // 最大总手续费，为0时不限制
+	MaxFeePercent  decimal.Decimal `json:"maxFeePercent"`            // 最大总手续费占金额的百分比，为0时不限制
+	IdempotencyKey string          `json:"idempotencyKey,omitempty"` // 也可通过Idempotency-Key头指定
+}
+
+// handleCreate 发起转账，提交到中继器后返回记录，之后在后台等待完成
+// 带幂等键的请求重复提交时返回同一笔转账
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	req := &transferRequest{}
+	if err := readJSON(w, r, req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	}
+	fromToken, toToken, err := s.parseTokens(req.FromToken, req.ToToken)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	amount, err := meson.ParseMesonAmount(req.Amount)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ToChain == "" {
+		writeError(w, http.StatusBadRequest, errors.New("缺少目标链"))
+		return
+	}
+	if req.Recipient != "" && !common.IsHexAddress(req.Recipient) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("无效的接收地址: %s", req.Recipient))
+		return
+	}
+
+	transfer := &meson.TransferRequest{
+		FromChain:      s.chain,
+		ToChain:        meson.Chain(req.ToChain),
+		FromToken:      fromToken,
+		ToToken:        toToken,
+		Amount:         amount,
+		Recipient:      req.Recipient,
+		SkipApprove:    req.SkipApprove,
+		ExpireIn:       time.Duration(req.ExpireIn) * time.Second,
+		IdempotencyKey: req.IdempotencyKey,
+	}
+	if req.MaxFee.IsPositive() || req.MaxFeePercent.IsPositive() {
+		transfer.FeeGuard = &meson.FeeGuard{MaxFee: req.MaxFee, MaxFeePercent: req.MaxFeePercent}
+	}
+
+	// 转账不随请求连接中断而中止
+	record, err := s.bridge.Transfer(s.ctx, s.sender, transfer)
+	if err != nil {
+		if record != nil {
+			writeJSON(w, statusFor(err), &errorResponse{Error: err.Error(), Transfer: record})
+			return
+		}
+		writeError(w, statusFor(err), err)
+		return
+	}
+	s.watch(record.ID, "")
+	w.Header().Set("Location", "/v1/transfers/"+record.ID)
+	writeJSON(w, http.StatusCreated, record)
+}
+
+// listResponse 转账列表
+type listResponse struct {
+	Transfers []*meson.SwapRecord `json:"transfers"`
+}
+
+// handleList 按创建时间列出转账，status参数按状态过滤，limit参数限制返回最新的条数
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	records, err := s.bridge.SwapStore().List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	query := r.URL.Query()
+	if status := query.Get("status"); status != "" {
+		filtered := records[:0]
+		for _, record := range records {
+			if string(record.Status) == status {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("无效的limit: %s", limitStr))
+			return
+		}
+		if len(records) > limit {
+			records = records[len(records)-limit:]
+		}
+	}
+	writeJSON(w, http.StatusOK, &listResponse{Transfers: records})
+}
+
+// handleGet 查询转账
+func (s *Server) handleGet(w http.ResponseWriter, id string) {
+	record, err := s.bridge.SwapStore().Get(id)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// handleCancel 取消转账，已提交的转账只能在过期后取消
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request, id string) {
+	// 先停止后台等待并等待其退出，避免与取消同时更新记录
+	s.mu.Lock()
+	running, watching := s.watches[id]
+	s.mu.Unlock()
+	if watching {
+		running.cancel()
+		<-running.done
+	}
+
+	// 与发起转账相同，取消交易不随客户端断开而中断
+	record, err := s.bridge.CancelTransfer(s.ctx, s.sender, id)
+	if err != nil {
+		if watching && record != nil && !record.Status.Final() {
+			s.watch(id, "")
+		}
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, record)
+}
+
+// watch 在后台等待转账完成，未提交的转账先通过幂等键继续执行
+func (s *Server) watch(id, idempotencyKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.watches[id]; exists {
+		return
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	running := &watcher{cancel: cancel, done: make(chan struct{})}
+	s.watches[id] = running
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer close(running.done)
+		defer func() {
+			s.mu.Lock()
+			if s.watches[id] == running {
+				delete(s.watches, id)
+			}
+			s.mu.Unlock()
+			cancel()
+		}()
+
+		record, err := s.bridge.SwapStore().Get(id)
+		if err == nil && record.Status != meson.StatusSubmitted && idempotencyKey != "" {
+			amount, parseErr := meson.ParseMesonAmount(record.Amount)
+			if parseErr != nil {
+				err = parseErr
+			} else {
+				_, err = s.bridge.Transfer(ctx, s.sender, &meson.TransferRequest{
+					FromChain:      record.FromChain,
+					ToChain:        record.ToChain,
+					FromToken:      record.FromToken,
+					ToToken:        record.ToToken,
+					Amount:         amount,
+					Recipient:      record.Recipient,
+					SkipApprove:    record.SkipApprove,
+					ExpireIn:       time.Duration(record.ExpireIn) * time.Second,
+					FeeGuard:       record.FeeGuard,
+					IdempotencyKey: idempotencyKey,
+				})
+			}
+		}
+		if err == nil {
+			record, err = s.bridge.WatchTransfer(ctx, s.sender, id)
+		}
+		switch {
+		case err != nil && ctx.Err() == nil:
+			helpers.Logf(s.config.Log, "转账%s执行失败: %v\n", id, err)
+		case err == nil && record.Status.Final():
+			helpers.Logf(s.config.Log, "转账%s已结束，状态: %s\n", id, record.Status)
+		}
+	}()
+}
+
+// parseTokens 解析源链和目标链代币，目标链代币为空时与源链相同
+func (s *Server) parseTokens(fromStr, toStr string) (meson.Token, meson.Token, error) {
+	parse := s.config.ParseToken
+	if parse == nil {
+		parse = func(token string) (meson.Token, error) { return meson.Token(token), nil }
+	}
+	if fromStr == "" {
+		return "", "", errors.New("缺少代币")
+	}
+	fromToken, err := parse(fromStr)
+	if err != nil {
+		return "", "", err
+	}
+	if toStr == "" {
+		return fromToken, fromToken, nil
+	}
+	toToken, err := parse(toStr)
+	if err != nil {
+		return "", "", err
+	}
+	return fromToken, toToken, nil
+}
+
+// errorResponse 错误响应，转账已创建但执行失败时附带转账记录
+type errorResponse struct {
+	Error    string            `json:"error"`
+	Transfer *meson.SwapRecord `json:"transfer,omitempty"`
+}
+
+// statusFor 按错误类型确定HTTP状态码
+func statusFor(err error) int {
+	var apiErr *meson.APIError
+	switch {
+	case errors.Is(err, meson.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, meson.ErrIdempotencyConflict), errors.Is(err, meson.ErrTransferFinal), errors.Is(err, meson.ErrSwapNotExpired):
+		return http.StatusConflict
+	case errors.Is(err, meson.ErrFeeExceeded):
+		return http.StatusUnprocessableEntity
+	case errors.As(err, &apiErr):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// readJSON 解析请求体
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("无效的请求: %w", err)
+	}
+	return nil
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError 输出错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+func newTestServer(t *testing.T) (*Server, *meson.Bridge) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	sender := helpers.NewSender(nil, big.NewInt(4200), key)
+	bridge := meson.NewBridge()
+
+	now := time.Now()
+	for _, record := range []*meson.SwapRecord{
+		{ID: "a1", Status: meson.StatusCreated, FromAddress: sender.From().Hex(), CreatedAt: now},
+		{ID: "b2", Status: meson.StatusCompleted, FromAddress: sender.From().Hex(), CreatedAt: now.Add(time.Second)},
+	} {
+		require.NoError(t, bridge.SwapStore().Save(record))
+	}
+	return New(context.Background(), bridge, sender, meson.ChainMerlin, Config{Tokens: []string{"secret"}}), bridge
+}
+
+func do(t *testing.T, s *Server, method, path, token, body string) (*httptest.ResponseRecorder, map[string]any) {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	var resp map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return rec, resp
+}
+
+func TestServer_Auth(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	rec, _ := do(t, s, http.MethodGet, "/healthz", "", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec, _ = do(t, s, http.MethodGet, "/v1/transfers", "", "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = do(t, s, http.MethodGet, "/v1/transfers", "wrong", "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec, _ = do(t, s, http.MethodGet, "/v1/transfers", "secret", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_Transfers(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	_, resp := do(t, s, http.MethodGet, "/v1/transfers", "secret", "")
+	require.Len(t, resp["transfers"], 2)
+	_, resp = do(t, s, http.MethodGet, "/v1/transfers?status=completed", "secret", "")
+	require.Len(t, resp["transfers"], 1)
+	_, resp = do(t, s, http.MethodGet, "/v1/transfers?limit=1", "secret", "")
+	require.Equal(t, "b2", resp["transfers"].([]any)[0].(map[string]any)["id"])
+
+	rec, resp := do(t, s, http.MethodGet, "/v1/transfers/a1", "secret", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "created", resp["status"])
+	rec, _ = do(t, s, http.MethodGet, "/v1/transfers/missing", "secret", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	// 未提交的转账直接取消，已结束的转账不能取消
+	rec, resp = do(t, s, http.MethodPost, "/v1/transfers/a1/cancel", "secret", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "cancelled", resp["status"])
+	rec, _ = do(t, s, http.MethodPost, "/v1/transfers/a1/cancel", "secret", "")
+	require.Equal(t, http.StatusConflict, rec.Code)
+
+	rec, _ = do(t, s, http.MethodDelete, "/v1/transfers/a1", "secret", "")
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestServer_CreateValidation(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	rec, _ := do(t, s, http.MethodPost, "/v1/transfers", "secret", `{"unknown": 1}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, _ = do(t, s, http.MethodPost, "/v1/transfers", "secret", `{"toChain": "bnb", "fromToken": "69", "amount": "abc"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	rec, resp := do(t, s, http.MethodPost, "/v1/transfers", "secret", `{"toChain": "bnb", "fromToken": "69", "amount": "6", "recipient": "0x123"}`)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, resp["error"], "接收地址")
+}
+
+func TestServer_CancelStopsWatch(t *testing.T) {
+	s, _ := newTestServer(t)
+
+	// 后台等待在收到取消后才退出，取消请求需等待其退出后再更新记录
+	running := &watcher{done: make(chan struct{})}
+	exited := false
+	running.cancel = func() {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			exited = true
+			close(running.done)
+		}()
+	}
+	s.watches["a1"] = running
+
+	rec, resp := do(t, s, http.MethodPost, "/v1/transfers/a1/cancel", "secret", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "cancelled", resp["status"])
+	require.True(t, exited)
+}
+
+func TestServer_ResumeLogsToConfigLog(t *testing.T) {
+	s, bridge := newTestServer(t)
+	var log bytes.Buffer
+	s.config.Log = &log
+
+	// 金额无法解析，后台继续执行失败，错误写入配置的日志输出
+	require.NoError(t, bridge.SwapStore().Save(&meson.SwapRecord{
+		ID: "c3", Status: meson.StatusCreated, FromAddress: s.sender.From().Hex(),
+		Amount: "abc", IdempotencyKey: "order-3", CreatedAt: time.Now(),
+	}))
+	require.NoError(t, s.Resume())
+	s.Wait()
+	require.Contains(t, log.String(), "转账c3执行失败")
+}
@@ -0,0 +1,448 @@
+// Package webhook 在跨链转账进入新阶段时向配置的地址发送签名的通知
+//
+// 每个通知为一个JSON事件，请求头包含:
+//
+//	X-Meson-Event-Id   事件ID，重试和重新投递时不变，接收方可据此去重
+//	X-Meson-Timestamp  发送时的Unix时间戳(秒)
+//	X-Meson-Signature  sha256=HMAC-SHA256(secret, timestamp + "." + body)的十六进制
+//
+// 返回2xx视为投递成功，否则按指数退避重试，最终失败的事件写入死信日志，之后可以通过Redeliver重新投递
+package webhook
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mer-coder/meson-bridge/pkg/helpers"
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+const (
+	// EventPhaseChanged 跨链转账进入新阶段
+	EventPhaseChanged = "swap.phase_changed"
+
+	// DefaultMaxAttempts 每个事件对每个地址的默认最大投递次数
+	DefaultMaxAttempts = 5
+	// DefaultBackoff 第一次重试前的默认等待时间，之后每次翻倍
+	DefaultBackoff = 2 * time.Second
+	// maxBackoff 两次重试之间的最长等待时间
+	maxBackoff = 5 * time.Minute
+
+	// lockRetry 等待死信日志锁的重试间隔
+	lockRetry = 50 * time.Millisecond
+	// staleLock 锁文件超过该时间视为持有者已崩溃
+	staleLock = time.Minute
+)
+
+// Endpoint 接收通知的地址
+type Endpoint struct {
+	URL    string            `json:"url"`
+	Secret string            `json:"secret"`           // HMAC签名密钥
+	Phases []meson.SwapPhase `json:"phases,omitempty"` // 只通知这些阶段，为空时通知所有阶段
+}
+
+// wants 是否需要通知该阶段
+func (e *Endpoint) wants(phase meson.SwapPhase) bool {
+	if len(e.Phases) == 0 {
+		return true
+	}
+	for _, p := range e.Phases {
+		if p == phase {
+			return true
+		}
+	}
+	return false
+}
+
+// Event 通知内容
+type Event struct {
+	ID            string            `json:"id"`
+	Type          string            `json:"type"`
+	Time          time.Time         `json:"time"`
+	TransferID    string            `json:"transferId"`
+	SwapID        string            `json:"swapId"`
+	Phase         meson.SwapPhase   `json:"phase"`
+	PreviousPhase meson.SwapPhase   `json:"previousPhase"`
+	Transfer      *meson.SwapRecord `json:"transfer"`
+}
+
+// DeadLetter 死信日志中的一项: 多次重试后仍投递失败的事件
+type DeadLetter struct {
+	URL       string    `json:"url"`
+	Event     *Event    `json:"event"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"lastError"`
+	FailedAt  time.Time `json:"failedAt"`
+}
+
+// Dispatcher 通知分发器，每个事件在独立的goroutine中投递到所有地址
+type Dispatcher struct {
+	endpoints  []*Endpoint
+	deadLetter string // 死信日志文件(JSON-lines)，为空时只打印日志
+	client     *http.Client
+
+	MaxAttempts int           // 最大投递次数
+	Backoff     time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	Log         io.Writer     // 日志输出，默认为标准错误
+
+	ctx    context.Context // 投递使用的上下文，Close超时后取消
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewDispatcher 创建通知分发器，deadLetterPath为死信日志文件
+func NewDispatcher(endpoints []*Endpoint, deadLetterPath string) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		endpoints:   endpoints,
+		deadLetter:  deadLetterPath,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: DefaultMaxAttempts,
+		Backoff:     DefaultBackoff,
+		Log:         os.Stderr,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Attach 在bridge上注册阶段变化回调
+func (d *Dispatcher) Attach(bridge *meson.Bridge) {
+	bridge.OnPhaseChange(d.Notify)
+}
+
+// Notify 为阶段变化生成事件并在后台投递
+func (d *Dispatcher) Notify(change *meson.PhaseChange) {
+	event := &Event{
+		ID:            newEventID(),
+		Type:          EventPhaseChanged,
+		Time:          change.Time,
+		TransferID:    change.Record.ID,
+		SwapID:        change.Record.SwapID,
+		Phase:         change.Phase,
+		PreviousPhase: change.Previous,
+		Transfer:      change.Record,
+	}
+	for _, endpoint := range d.endpoints {
+		if !endpoint.wants(event.Phase) {
+			continue
+		}
+		d.wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer d.wg.Done()
+			d.deliver(d.ctx, endpoint, event)
+		}(endpoint)
+	}
+}
+
+// Close 等待所有进行中的投递结束，ctx结束时取消仍在重试的投递，这些事件写入死信日志
+// Close之后的通知不再投递，直接写入死信日志
+func (d *Dispatcher) Close(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		d.cancel()
+		<-done
+	}
+	d.cancel()
+}
+
+// deliver 投递事件，失败时重试，最终失败的写入死信日志
+func (d *Dispatcher) deliver(ctx context.Context, endpoint *Endpoint, event *Event) error {
+	attempts, err := d.send(ctx, endpoint, event)
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		err = fmt.Errorf("投递已取消: %w", err)
+	}
+	helpers.Logf(d.Log, "通知%s投递到%s失败(共%d次): %v\n", event.ID, endpoint.URL, attempts, err)
+	if logErr := d.appendDeadLetter(&DeadLetter{
+		URL:       endpoint.URL,
+		Event:     event,
+		Attempts:  attempts,
+		LastError: err.Error(),
+		FailedAt:  time.Now(),
+	}); logErr != nil {
+		helpers.Logf(d.Log, "写入死信日志失败: %v\n", logErr)
+	}
+	return err
+}
+
+// send 按指数退避投递事件直到成功或达到最大次数，返回投递次数
+func (d *Dispatcher) send(ctx context.Context, endpoint *Endpoint, event *Event) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, fmt.Errorf("序列化通知失败: %w", err)
+	}
+	maxAttempts := d.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	delay := d.Backoff
+	for attempt := 1; ; attempt++ {
+		err = d.post(ctx, endpoint, event.ID, body)
+		if err == nil || attempt >= maxAttempts {
+			return attempt, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxBackoff {
+			delay = maxBackoff
+		}
+	}
+}
+
+// post 发送一次签名的请求
+func (d *Dispatcher) post(ctx context.Context, endpoint *Endpoint, eventID string, body []byte) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Meson-Event-Id", eventID)
+	req.Header.Set("X-Meson-Timestamp", timestamp)
+	req.Header.Set("X-Meson-Signature", "sha256="+Sign(endpoint.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign 计算通知的签名: HMAC-SHA256(secret, timestamp + "." + body)的十六进制
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验通知的签名，接收方可用于验证请求来源
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	expected := "sha256=" + Sign(secret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// appendDeadLetter 追加一项到死信日志
+func (d *Dispatcher) appendDeadLetter(letter *DeadLetter) error {
+	if d.deadLetter == "" {
+		return nil
+	}
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+	unlock, err := lockFile(context.Background(), d.deadLetter)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := os.OpenFile(d.deadLetter, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+// lockFile 通过独占创建锁文件锁定死信日志，serve和redeliver可能在不同进程中同时修改日志
+func lockFile(ctx context.Context, path string) (func(), error) {
+	lock := path + ".lock"
+	for {
+		file, err := os.OpenFile(lock, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lock) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("锁定死信日志失败: %w", err)
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(lock)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("锁定死信日志失败: %w", ctx.Err())
+		case <-time.After(lockRetry):
+		}
+	}
+}
+
+// ReadDeadLetters 读取死信日志，文件不存在时返回空列表
+func ReadDeadLetters(path string) ([]*DeadLetter, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取死信日志失败: %w", err)
+	}
+	defer file.Close()
+
+	var letters []*DeadLetter
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		letter := &DeadLetter{}
+		if err := json.Unmarshal(scanner.Bytes(), letter); err != nil {
+			return nil, fmt.Errorf("死信日志第%d行: %w", line, err)
+		}
+		letters = append(letters, letter)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取死信日志失败: %w", err)
+	}
+	return letters, nil
+}
+
+// RedeliverResult 重新投递一项死信的结果
+type RedeliverResult struct {
+	EventID string `json:"eventId"`
+	URL     string `json:"url"`
+	Error   string `json:"error,omitempty"` // 为空表示投递成功
+}
+
+// letterKey 死信的标识: 同一事件可能对多个地址投递失败
+type letterKey struct {
+	eventID string
+	url     string
+}
+
+// Redeliver 重新投递死信日志中的事件，eventIDs为空时投递全部
+// 投递成功的项从日志中移除，仍然失败的项保留并更新失败原因；地址已不在配置中的项保留不动
+func (d *Dispatcher) Redeliver(ctx context.Context, eventIDs ...string) ([]*RedeliverResult, error) {
+	if d.deadLetter == "" {
+		return nil, errors.New("未配置死信日志")
+	}
+	letters, err := ReadDeadLetters(d.deadLetter)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]bool)
+	for _, id := range eventIDs {
+		selected[id] = true
+	}
+	endpoints := make(map[string]*Endpoint)
+	for _, endpoint := range d.endpoints {
+		endpoints[endpoint.URL] = endpoint
+	}
+
+	var results []*RedeliverResult
+	delivered := make(map[letterKey]bool)
+	failed := make(map[letterKey]*DeadLetter)
+	for _, letter := range letters {
+		if len(selected) > 0 && !selected[letter.Event.ID] {
+			continue
+		}
+		result := &RedeliverResult{EventID: letter.Event.ID, URL: letter.URL}
+		results = append(results, result)
+
+		endpoint, ok := endpoints[letter.URL]
+		if !ok {
+			result.Error = "地址已不在配置中"
+			continue
+		}
+		key := letterKey{letter.Event.ID, letter.URL}
+		attempts, err := d.send(ctx, endpoint, letter.Event)
+		if err != nil {
+			result.Error = err.Error()
+			failed[key] = &DeadLetter{Attempts: attempts, LastError: err.Error(), FailedAt: time.Now()}
+		} else {
+			delivered[key] = true
+		}
+	}
+
+	if err := d.updateDeadLetters(ctx, delivered, failed); err != nil {
+		return results, err
+	}
+	return results, nil
+}
+
+// updateDeadLetters 重新读取死信日志，移除已投递的项并更新仍然失败的项
+// 投递期间其他进程追加的项原样保留
+func (d *Dispatcher) updateDeadLetters(ctx context.Context, delivered map[letterKey]bool, failed map[letterKey]*DeadLetter) error {
+	if len(delivered) == 0 && len(failed) == 0 {
+		return nil
+	}
+	unlock, err := lockFile(ctx, d.deadLetter)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	letters, err := ReadDeadLetters(d.deadLetter)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, letter := range letters {
+		key := letterKey{letter.Event.ID, letter.URL}
+		if delivered[key] {
+			continue
+		}
+		if retry, ok := failed[key]; ok {
+			letter.Attempts += retry.Attempts
+			letter.LastError = retry.LastError
+			letter.FailedAt = retry.FailedAt
+		}
+		data, err := json.Marshal(letter)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	tmp := d.deadLetter + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("写入死信日志失败: %w", err)
+	}
+	if err := os.Rename(tmp, d.deadLetter); err != nil {
+		return fmt.Errorf("写入死信日志失败: %w", err)
+	}
+	return nil
+}
+
+// newEventID 生成随机事件ID
+func newEventID() string {
+	var b [12]byte
+	rand.Read(b[:])
+	return "evt_" + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,142 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/mer-coder/meson-bridge/pkg/meson"
+)
+
+func phaseChange(phase meson.SwapPhase) *meson.PhaseChange {
+	return &meson.PhaseChange{
+		Record:   &meson.SwapRecord{ID: "r1", SwapID: "0xabc", Status: meson.StatusSubmitted, Phase: phase},
+		Previous: meson.PhasePosted,
+		Phase:    phase,
+		Time:     time.Now(),
+	}
+}
+
+func TestDispatcher_Signature(t *testing.T) {
+	received := make(chan bool, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- Verify("secret", r.Header.Get("X-Meson-Timestamp"), body, r.Header.Get("X-Meson-Signature"))
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher([]*Endpoint{
+		{URL: srv.URL, Secret: "secret"},
+		{URL: srv.URL, Secret: "secret", Phases: []meson.SwapPhase{meson.PhaseExecuted}},
+	}, "")
+	d.Notify(phaseChange(meson.PhaseBonded))
+	d.Close(context.Background())
+
+	require.True(t, <-received)
+	require.Len(t, received, 0) // 第二个地址只接收EXECUTED
+}
+
+func TestDispatcher_DeadLetterAndRedeliver(t *testing.T) {
+	var (
+		healthy  atomic.Bool
+		attempts atomic.Int32
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	d := NewDispatcher([]*Endpoint{{URL: srv.URL, Secret: "secret"}}, path)
+	d.MaxAttempts = 3
+	d.Backoff = time.Millisecond
+	d.Notify(phaseChange(meson.PhaseExecuted))
+	d.Close(context.Background())
+
+	require.EqualValues(t, 3, attempts.Load())
+	letters, err := ReadDeadLetters(path)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	require.Equal(t, 3, letters[0].Attempts)
+	require.Equal(t, meson.PhaseExecuted, letters[0].Event.Phase)
+
+	// 仍然失败的项保留在死信日志中
+	results, err := d.Redeliver(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NotEmpty(t, results[0].Error)
+	letters, err = ReadDeadLetters(path)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	require.Equal(t, 6, letters[0].Attempts)
+
+	healthy.Store(true)
+	results, err = d.Redeliver(context.Background(), letters[0].Event.ID)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Empty(t, results[0].Error)
+	letters, err = ReadDeadLetters(path)
+	require.NoError(t, err)
+	require.Empty(t, letters)
+}
+
+func TestDispatcher_RedeliverKeepsConcurrentLetters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	// 另一个进程(如serve)使用独立的Dispatcher写入同一个死信日志
+	other := NewDispatcher(nil, path)
+	appended := &DeadLetter{URL: "http://other", Event: &Event{ID: "evt_new"}, Attempts: 5}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, other.appendDeadLetter(appended))
+	}))
+	defer srv.Close()
+
+	d := NewDispatcher([]*Endpoint{{URL: srv.URL, Secret: "secret"}}, path)
+	require.NoError(t, d.appendDeadLetter(&DeadLetter{URL: srv.URL, Event: &Event{ID: "evt_old"}, Attempts: 5}))
+
+	results, err := d.Redeliver(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Empty(t, results[0].Error)
+
+	letters, err := ReadDeadLetters(path)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	require.Equal(t, "evt_new", letters[0].Event.ID)
+}
+
+func TestDispatcher_CloseCancelsRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	d := NewDispatcher([]*Endpoint{{URL: srv.URL, Secret: "secret"}}, path)
+	d.Log = io.Discard
+	d.Backoff = time.Hour
+	d.Notify(phaseChange(meson.PhaseExecuted))
+
+	// 超时后取消仍在等待重试的投递，事件写入死信日志
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	d.Close(ctx)
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	letters, err := ReadDeadLetters(path)
+	require.NoError(t, err)
+	require.Len(t, letters, 1)
+	require.Equal(t, 1, letters[0].Attempts)
+	require.Contains(t, letters[0].LastError, "投递已取消")
+}